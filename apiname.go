@@ -0,0 +1,69 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+)
+
+// RestApisGetter is implemented by clients able to list the REST APIs, as [*apigateway.Client] does.
+type RestApisGetter interface {
+	GetRestApis(context.Context, *apigateway.GetRestApisInput, ...func(*apigateway.Options)) (*apigateway.GetRestApisOutput, error)
+}
+
+// NewTransportByAPIName creates the transport of the REST API named name, as API IDs change across
+// environments but names are stable. The client must be a [RestApisGetter].
+// [ErrAPINotFound] is returned when no API has the name, and [ErrAmbiguousAPIName] when several do.
+func NewTransportByAPIName(client ApiGwClient, name string, opts ...Option) (*Transport, error) {
+	return NewTransportByAPINameContext(context.Background(), client, name, opts...)
+}
+
+// NewTransportByAPINameContext is [NewTransportByAPIName] bounding the APIs listing with ctx.
+func NewTransportByAPINameContext(ctx context.Context, client ApiGwClient, name string, opts ...Option) (*Transport, error) {
+	apiID, err := resolveAPIName(ctx, client, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTransport(client, apiID, opts...), nil
+}
+
+func resolveAPIName(ctx context.Context, client ApiGwClient, name string) (string, error) {
+	getter, ok := client.(RestApisGetter)
+	if !ok {
+		return "", fmt.Errorf("%w: GetRestApis", ErrOperationNotSupported)
+	}
+
+	var ids []string
+
+	for input := (&apigateway.GetRestApisInput{}); ; {
+		out, err := getter.GetRestApis(ctx, input)
+		if err != nil {
+			return "", fmt.Errorf("get rest apis error: %w", err)
+		}
+
+		for _, api := range out.Items {
+			if aws.ToString(api.Name) == name {
+				ids = append(ids, aws.ToString(api.Id))
+			}
+		}
+
+		if aws.ToString(out.Position) == "" {
+			break
+		}
+
+		input = &apigateway.GetRestApisInput{Position: out.Position}
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("%w: %q", ErrAPINotFound, name)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf("%w: %q is the name of the APIs %s", ErrAmbiguousAPIName, name, strings.Join(ids, ", "))
+	}
+}
@@ -0,0 +1,173 @@
+package transport
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+)
+
+// noBasePath is the base path of mappings without one.
+const noBasePath = "(none)"
+
+// DomainNamesGetter is implemented by clients able to list custom domain names, as [*apigateway.Client] does.
+type DomainNamesGetter interface {
+	GetDomainNames(context.Context, *apigateway.GetDomainNamesInput, ...func(*apigateway.Options)) (*apigateway.GetDomainNamesOutput, error)
+}
+
+// BasePathMappingsGetter is implemented by clients able to list the base path mappings of
+// a custom domain name, as [*apigateway.Client] does.
+type BasePathMappingsGetter interface {
+	GetBasePathMappings(context.Context, *apigateway.GetBasePathMappingsInput, ...func(*apigateway.Options)) (*apigateway.GetBasePathMappingsOutput, error)
+}
+
+type basePathMapping struct {
+	basePath string
+	apiID    string
+	stage    string
+}
+
+// resolveBasePath returns the API, the stage and the path without base path a custom domain request is mapped to.
+// Requests to other hosts, or not matching any base path, are unchanged and have no stage.
+func (t *Transport) resolveBasePath(ctx context.Context, host, path string) (string, string, string, error) {
+	mappings, err := t.basePathMappings(ctx, strings.ToLower(host))
+	if err != nil {
+		return "", "", "", err
+	}
+
+	for _, m := range mappings {
+		if m.basePath == "" {
+			return m.apiID, m.stage, path, nil
+		}
+
+		if rest, found := strings.CutPrefix(path, "/"+m.basePath); found && (rest == "" || rest[0] == '/') {
+			t.requestLog(ctx).DebugContext(ctx, "base path mapped", slog.String("base_path", m.basePath),
+				slog.String("mapped_rest_api_id", m.apiID), slog.String("stage", m.stage))
+
+			return m.apiID, m.stage, cmp.Or(rest, "/"), nil
+		}
+	}
+
+	return t.apiID, "", path, nil
+}
+
+// basePathMappings returns the base path mappings of host, longest base paths first.
+// Domain names and mappings are fetched on first use.
+func (t *Transport) basePathMappings(ctx context.Context, host string) ([]basePathMapping, error) {
+	domainsGetter, isDomainsGetter := t.client.(DomainNamesGetter)
+	mappingsGetter, isMappingsGetter := t.client.(BasePathMappingsGetter)
+
+	if !isDomainsGetter || !isMappingsGetter {
+		return nil, fmt.Errorf("%w: GetDomainNames and GetBasePathMappings", ErrOperationNotSupported)
+	}
+
+	if err := t.fetchCustomDomains(ctx, domainsGetter); err != nil {
+		return nil, err
+	}
+
+	lookup := func() ([]basePathMapping, bool) {
+		t.overridesMu.Lock()
+		defer t.overridesMu.Unlock()
+
+		mappings, isCustomDomain := t.customDomains[host]
+
+		return mappings, !isCustomDomain || mappings != nil
+	}
+
+	return fetchOnce(ctx, &t.overridesFlights, "base paths "+host, lookup, func() ([]basePathMapping, error) {
+		mappings := []basePathMapping{}
+
+		for input := (&apigateway.GetBasePathMappingsInput{DomainName: aws.String(host)}); ; {
+			out, err := mappingsGetter.GetBasePathMappings(ctx, input)
+			if err != nil {
+				return nil, fmt.Errorf("get base path mappings error: %w", err)
+			}
+
+			for _, m := range out.Items {
+				basePath := aws.ToString(m.BasePath)
+				if basePath == noBasePath {
+					basePath = ""
+				}
+
+				mappings = append(mappings, basePathMapping{
+					basePath: strings.Trim(basePath, "/"),
+					apiID:    aws.ToString(m.RestApiId),
+					stage:    aws.ToString(m.Stage),
+				})
+			}
+
+			if aws.ToString(out.Position) == "" {
+				break
+			}
+
+			input = &apigateway.GetBasePathMappingsInput{DomainName: aws.String(host), Position: out.Position}
+		}
+
+		slices.SortFunc(mappings, func(a, b basePathMapping) int {
+			return len(b.basePath) - len(a.basePath)
+		})
+
+		t.overridesMu.Lock()
+		defer t.overridesMu.Unlock()
+
+		t.customDomains[host] = mappings
+
+		return mappings, nil
+	})
+}
+
+// fetchCustomDomains fetches the custom domain names on first use.
+func (t *Transport) fetchCustomDomains(ctx context.Context, getter DomainNamesGetter) error {
+	lookup := func() (struct{}, bool) {
+		t.overridesMu.Lock()
+		defer t.overridesMu.Unlock()
+
+		return struct{}{}, t.customDomains != nil
+	}
+
+	_, err := fetchOnce(ctx, &t.overridesFlights, "domain names", lookup, func() (struct{}, error) {
+		domains := map[string][]basePathMapping{}
+
+		for input := (&apigateway.GetDomainNamesInput{}); ; {
+			out, err := getter.GetDomainNames(ctx, input)
+			if err != nil {
+				return struct{}{}, fmt.Errorf("get domain names error: %w", err)
+			}
+
+			for _, d := range out.Items {
+				domains[strings.ToLower(aws.ToString(d.DomainName))] = nil
+			}
+
+			if aws.ToString(out.Position) == "" {
+				break
+			}
+
+			input = &apigateway.GetDomainNamesInput{Position: out.Position}
+		}
+
+		t.overridesMu.Lock()
+		defer t.overridesMu.Unlock()
+
+		t.customDomains = domains
+
+		return struct{}{}, nil
+	})
+
+	return err
+}
+
+// WithBasePathMappings resolves the base path mappings of custom domains: requests to a custom
+// domain host have the mapped base path stripped and are routed to the mapped API. They are invoked
+// with the mapped stage, as the [InvokeContext] Stage, when it is not the [WithStage] one.
+// The client must implement [DomainNamesGetter] and [BasePathMappingsGetter], and [StageGetter]
+// for the mapped stages.
+func WithBasePathMappings() Option {
+	return func(t *Transport) {
+		t.resolveBasePaths = true
+	}
+}
@@ -0,0 +1,126 @@
+package transport_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+
+	"github.com/rcarrion2/aws-apigw-invoke-transport"
+	"github.com/rcarrion2/aws-apigw-invoke-transport/transporttest"
+)
+
+// benchClient is a fixed response client, so the benchmarks measure the transport only.
+type benchClient struct {
+	resources []types.Resource
+	out       *apigateway.TestInvokeMethodOutput
+}
+
+func (c benchClient) TestInvokeMethod(
+	context.Context,
+	*apigateway.TestInvokeMethodInput,
+	...func(*apigateway.Options),
+) (*apigateway.TestInvokeMethodOutput, error) {
+	return c.out, nil
+}
+
+func (c benchClient) GetResources(
+	context.Context,
+	*apigateway.GetResourcesInput,
+	...func(*apigateway.Options),
+) (*apigateway.GetResourcesOutput, error) {
+	return &apigateway.GetResourcesOutput{Items: c.resources}, nil
+}
+
+func (c benchClient) Options() apigateway.Options {
+	return apigateway.Options{Region: transporttest.Region}
+}
+
+// benchResources returns the test resources plus n resources with a path variable.
+func benchResources(n int) []types.Resource {
+	resources := createResources()
+
+	for i := range n {
+		resources = append(resources, types.Resource{
+			Id:              aws.String(fmt.Sprintf("r%04d", i)),
+			Path:            aws.String(fmt.Sprintf("/api/v1/resources%04d/{id}", i)),
+			ResourceMethods: map[string]types.Method{http.MethodGet: {}, http.MethodPost: {}},
+		})
+	}
+
+	return resources
+}
+
+func newBenchTransport(b *testing.B, resources int) *transport.Transport {
+	b.Helper()
+
+	tr, err := transport.NewInitializedTransport(benchClient{
+		resources: benchResources(resources),
+		out: &apigateway.TestInvokeMethodOutput{
+			Status:            http.StatusOK,
+			Body:              aws.String(`{"id":"john.doe","name":"John Doe"}`),
+			MultiValueHeaders: map[string][]string{"content-type": {"application/json"}, "x-request-id": {"0123456789"}},
+		},
+	}, "abc123")
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return tr
+}
+
+func BenchmarkTransport_RoundTrip(b *testing.B) {
+	body := strings.Repeat(`{"name":"john.doe"}`, 512)
+
+	testCases := map[string]struct {
+		resources int
+		method    string
+		path      string
+		body      string
+	}{
+		"GET":                {method: http.MethodGet, path: "/api/v1/users/john.doe?fields=name"},
+		"POST 10KB body":     {method: http.MethodPost, path: "/api/v1/users", body: body},
+		"GET 500 resources":  {resources: 500, method: http.MethodGet, path: "/api/v1/users/john.doe"},
+		"GET last resources": {resources: 500, method: http.MethodGet, path: "/api/v1/resources0499/42"},
+	}
+
+	for name, tc := range testCases {
+		b.Run(name, func(b *testing.B) {
+			tr := newBenchTransport(b, tc.resources)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for range b.N {
+				r := createRequest(tc.method, "https://custom-domain.com", tc.path, strings.NewReader(tc.body))
+
+				resp, err := tr.RoundTrip(r)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				_ = resp.Body.Close()
+			}
+		})
+	}
+}
+
+func BenchmarkTransport_Match(b *testing.B) {
+	tr := newBenchTransport(b, 500)
+	r := createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/resources0499/42", http.NoBody)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		if _, err := tr.Match(r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultBinaryMediaTypes are the request content types always encoded as binary.
+var defaultBinaryMediaTypes = []string{
+	"application/octet-stream",
+	"application/zip",
+	"application/gzip",
+	"application/pdf",
+	"application/protobuf",
+	"application/x-protobuf",
+	"image/*",
+	"audio/*",
+	"video/*",
+}
+
+// isBinaryBody reports whether the body must be base64 encoded, that is when the content type
+// matches one of the binary media types, or when the body is not valid UTF-8 text.
+// Multipart bodies are binary when one of their parts is (e.g. an image/png file upload).
+func isBinaryBody(contentType string, body []byte, binaryMediaTypes []string) bool {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err == nil && isBinaryMediaType(mediaType, binaryMediaTypes) {
+		return true
+	}
+
+	if !utf8.Valid(body) {
+		return true
+	}
+
+	return err == nil && strings.HasPrefix(mediaType, "multipart/") &&
+		hasBinaryPart(body, params["boundary"], binaryMediaTypes)
+}
+
+func isBinaryMediaType(mediaType string, binaryMediaTypes []string) bool {
+	for _, binaryType := range binaryMediaTypes {
+		if matchMediaType(binaryType, mediaType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasBinaryPart reports whether a part of the multipart body has a binary media type.
+// The parts are read raw, their bytes are never decoded nor altered.
+func hasBinaryPart(body []byte, boundary string, binaryMediaTypes []string) bool {
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+
+	for {
+		part, err := reader.NextRawPart()
+		if err != nil {
+			return false
+		}
+
+		if mediaType, _, err := mime.ParseMediaType(part.Header.Get("Content-Type")); err == nil &&
+			isBinaryMediaType(mediaType, binaryMediaTypes) {
+			return true
+		}
+	}
+}
+
+// validateMultipart checks a multipart body is delimited by the boundary of its content type,
+// as the backend could not parse it otherwise. Other bodies are not checked.
+func validateMultipart(contentType string, body []byte) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return nil
+	}
+
+	if params["boundary"] == "" {
+		return fmt.Errorf("%w: no boundary in content type %q", ErrInvalidMultipart, contentType)
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+
+	for parts := 0; ; parts++ {
+		part, err := reader.NextRawPart()
+		// only a bare EOF ends a body, the wrapped ones (e.g. multipart: NextPart: EOF) are malformed bodies
+		if err == io.EOF && parts > 0 {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidMultipart, err)
+		}
+
+		if _, err = io.Copy(io.Discard, part); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidMultipart, err)
+		}
+	}
+}
+
+// matchMediaType matches a media type against a pattern supporting wildcards (e.g. image/*, */*).
+func matchMediaType(pattern, mediaType string) bool {
+	if pattern == "*/*" || strings.EqualFold(pattern, mediaType) {
+		return true
+	}
+
+	prefix, isWildcard := strings.CutSuffix(pattern, "/*")
+
+	return isWildcard && strings.HasPrefix(strings.ToLower(mediaType), strings.ToLower(prefix)+"/")
+}
+
+// WithBinaryMediaTypes adds content types (wildcards like image/* are supported)
+// whose request bodies are base64 encoded, as API Gateway expects for binary payloads.
+func WithBinaryMediaTypes(mediaTypes ...string) Option {
+	return func(t *Transport) {
+		t.binaryMediaTypes = append(t.binaryMediaTypes, mediaTypes...)
+	}
+}
@@ -0,0 +1,126 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+)
+
+// CircuitBreaker configures the per-route circuit breaker of [WithCircuitBreaker].
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive failures opening the circuit of a route.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before half-open probes are let through.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of concurrent probes let through a half-open circuit, 1 when zero.
+	// A successful probe closes the circuit, a failed one opens it again.
+	HalfOpenProbes int
+}
+
+// WithCircuitBreaker fails fast with [ErrCircuitOpen] the requests to routes whose invokes keep failing,
+// so a backend down does not waste TestInvokeMethod quota. Invoke errors and 5xx responses are failures.
+func WithCircuitBreaker(cb CircuitBreaker) Option {
+	return func(t *Transport) {
+		t.breaker = &breaker{config: cb, circuits: map[string]*circuit{}}
+	}
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type circuit struct {
+	state    circuitState
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+type breaker struct {
+	config CircuitBreaker
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// allow reports whether the route can be invoked now, and whether the invoke is a half-open probe.
+func (b *breaker) allow(key string, now time.Time) (bool, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, found := b.circuits[key]
+	if !found {
+		return true, false
+	}
+
+	if c.state == circuitOpen && now.Sub(c.openedAt) >= b.config.OpenTimeout {
+		c.state, c.probes = circuitHalfOpen, 0
+	}
+
+	switch c.state {
+	case circuitOpen:
+		return false, false
+	case circuitHalfOpen:
+		if c.probes >= max(b.config.HalfOpenProbes, 1) {
+			return false, false
+		}
+
+		c.probes++
+
+		return true, true
+	default:
+		return true, false
+	}
+}
+
+func (b *breaker) record(key string, failed, probe bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, found := b.circuits[key]
+
+	switch {
+	case !failed:
+		if found && (c.state == circuitClosed || probe) {
+			delete(b.circuits, key)
+		}
+	case !found:
+		c = &circuit{}
+		b.circuits[key] = c
+		fallthrough
+	default:
+		c.failures++
+
+		if probe || (c.state == circuitClosed && c.failures >= b.config.FailureThreshold) {
+			c.state, c.openedAt = circuitOpen, now
+		}
+	}
+}
+
+// breaking wraps next with the [WithCircuitBreaker] circuit of the route.
+func (t *Transport) breaking(next Invoker, res resource) Invoker {
+	if t.breaker == nil {
+		return next
+	}
+
+	key := endpointKey(res.method, res.path)
+
+	return InvokerFunc(func(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error) {
+		allowed, probe := t.breaker.allow(key, t.clock.Now())
+		if !allowed {
+			return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, key)
+		}
+
+		resp, err := next.Invoke(r, input)
+		t.breaker.record(key, err != nil || resp.StatusCode >= http.StatusInternalServerError, probe, t.clock.Now())
+
+		return resp, err
+	})
+}
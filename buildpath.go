@@ -0,0 +1,55 @@
+package transport
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/rcarrion2/aws-apigw-invoke-transport/internal/routetemplate"
+)
+
+// BuildPath builds the request path of a mapped route from its template and the values of its path variables,
+// e.g. /api/v1/users/john for GET /api/v1/users/{value} with {"value": "john"}. Values are escaped, greedy
+// variables (e.g. {proxy+}) keep their slashes. It fails with [ErrResourceNotFound] when the route is not mapped
+// and with [ErrMissingPathParameters] when a template variable has no value.
+func (t *Transport) BuildPath(method, template string, params map[string]string) (string, error) {
+	if _, found := t.currentMapping()[endpointKey(method, template)]; !found {
+		return "", fmt.Errorf("%w: %s %s", ErrResourceNotFound, method, template)
+	}
+
+	segments := strings.Split(template, "/")
+
+	var missing []string
+
+	for i, segment := range segments {
+		kind := routetemplate.SegmentKind(segment)
+		if kind == routetemplate.Literal {
+			continue
+		}
+
+		name := routetemplate.VarName(segment)
+
+		value, found := params[name]
+		if !found || value == "" {
+			missing = append(missing, name)
+			continue
+		}
+
+		if kind == routetemplate.Greedy {
+			parts := strings.Split(value, "/")
+			for j, part := range parts {
+				parts[j] = url.PathEscape(part)
+			}
+
+			segments[i] = strings.Join(parts, "/")
+		} else {
+			segments[i] = url.PathEscape(value)
+		}
+	}
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrMissingPathParameters, strings.Join(missing, ", "))
+	}
+
+	return strings.Join(segments, "/"), nil
+}
@@ -0,0 +1,336 @@
+package transport
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+)
+
+// CachedResponse is a response stored by the [WithResponseCache] cache.
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Expires is when the response becomes stale, stale responses with an ETag are revalidated.
+	Expires time.Time
+	// Vary is the hash of the request headers named by the response Vary header, the response is
+	// only served to requests with the same values.
+	Vary string
+}
+
+// CacheStore stores the cached responses, keyed by API, stage and path with query.
+// Implementations must be safe for concurrent use.
+type CacheStore interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse)
+}
+
+// defaultMemoryCacheEntries is the [NewMemoryCache] size when not set.
+const defaultMemoryCacheEntries = 10_000
+
+type memoryCache struct {
+	mu         sync.RWMutex
+	entries    map[string]*CachedResponse
+	maxEntries int
+	clock      Clock
+}
+
+// NewMemoryCache returns an in-memory [CacheStore] holding up to maxEntries responses, 10000 when zero.
+// Once full, the expired entries are evicted, and the entry expiring first when none is.
+func NewMemoryCache(maxEntries int) CacheStore {
+	if maxEntries <= 0 {
+		maxEntries = defaultMemoryCacheEntries
+	}
+
+	return &memoryCache{entries: map[string]*CachedResponse{}, maxEntries: maxEntries, clock: systemClock{}}
+}
+
+func (c *memoryCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	resp, found := c.entries[key]
+
+	return resp, found
+}
+
+func (c *memoryCache) Set(key string, resp *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, found := c.entries[key]; !found && len(c.entries) >= c.maxEntries {
+		c.evict()
+	}
+
+	c.entries[key] = resp
+}
+
+// evict removes the expired entries, or else the entry expiring first, c.mu is held.
+func (c *memoryCache) evict() {
+	now := c.clock.Now()
+
+	var (
+		firstKey     string
+		firstExpires time.Time
+	)
+
+	for key, resp := range c.entries {
+		if !now.Before(resp.Expires) {
+			delete(c.entries, key)
+			continue
+		}
+
+		if firstKey == "" || resp.Expires.Before(firstExpires) {
+			firstKey, firstExpires = key, resp.Expires
+		}
+	}
+
+	if len(c.entries) >= c.maxEntries {
+		delete(c.entries, firstKey)
+	}
+}
+
+// WithResponseCache caches the GET responses as a private HTTP cache would: 200 responses are stored
+// for their Cache-Control max-age (no-store ones are not) and fresh hits skip TestInvokeMethod.
+// Stale responses with an ETag are revalidated with If-None-Match. The store defaults to [NewMemoryCache].
+// Requests invoking with [InvokeContext] Credentials are not cached, as the key does not hold the identity:
+// share a store only between transports whose clients have the same credentials.
+//
+// Cache hits are not invoked, [InvokeLatency] and [OutputFromResponse] are false for them.
+//
+// Responses are keyed by API, stage and path with query, plus the stage variables and the
+// Authorization header when set: they are hashed, the tokens are never stored. The Vary header is
+// honored, Vary: * responses are not stored.
+func WithResponseCache(store CacheStore) Option {
+	return func(t *Transport) {
+		if store == nil {
+			store = NewMemoryCache(0)
+		}
+
+		t.cache = store
+	}
+}
+
+// caching wraps next with the [WithResponseCache] cache, ic holds the overrides of the request
+// (with the stage it is invoked on).
+func (t *Transport) caching(next Invoker, ic InvokeContext) Invoker {
+	if t.cache == nil || ic.Credentials != nil {
+		return next
+	}
+
+	stage := cmp.Or(ic.Stage, t.stage)
+
+	return InvokerFunc(func(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error) {
+		if r.Method != http.MethodGet || hasCacheDirective(r.Header, "no-store") {
+			return next.Invoke(r, input)
+		}
+
+		key := cacheKey(stage, input)
+		cached, found := t.cache.Get(key)
+		found = found && cached.Vary == varyHash(cached.Header, input.MultiValueHeaders)
+
+		if found && t.clock.Now().Before(cached.Expires) && !hasCacheDirective(r.Header, "no-cache") {
+			return cached.response(r), nil
+		}
+
+		etag := ""
+		if found {
+			etag = cached.Header.Get("ETag")
+		}
+
+		if etag != "" {
+			revalidation := *input
+			revalidation.MultiValueHeaders = cloneMultiValueHeaders(input.MultiValueHeaders)
+			revalidation.MultiValueHeaders["If-None-Match"] = []string{etag}
+			input = &revalidation
+		}
+
+		resp, err := next.Invoke(r, input)
+		if err != nil {
+			return nil, err
+		}
+
+		if etag != "" && resp.StatusCode == http.StatusNotModified {
+			_ = resp.Body.Close()
+
+			revalidated := *cached
+			revalidated.Expires = t.clock.Now().Add(maxAge(resp.Header))
+			t.cache.Set(key, &revalidated)
+
+			return revalidated.response(resp.Request), nil
+		}
+
+		if resp.StatusCode != http.StatusOK || hasCacheDirective(resp.Header, "no-store") || varyAll(resp.Header) {
+			return resp, nil
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		if age := maxAge(resp.Header); age > 0 || resp.Header.Get("ETag") != "" {
+			t.cache.Set(key, &CachedResponse{
+				StatusCode: resp.StatusCode,
+				Header:     resp.Header.Clone(),
+				Body:       body,
+				Expires:    t.clock.Now().Add(age),
+				Vary:       varyHash(resp.Header, input.MultiValueHeaders),
+			})
+		}
+
+		return resp, nil
+	})
+}
+
+// cacheKey returns the cache key of the invoke input: the API, the stage and the path with query,
+// with the hash of the stage variables and the Authorization header when any is set.
+func cacheKey(stage string, input *apigateway.TestInvokeMethodInput) string {
+	key := aws.ToString(input.RestApiId) + " " + stage + " " + aws.ToString(input.PathWithQueryString)
+
+	auth := http.Header(input.MultiValueHeaders).Values("Authorization")
+	if len(input.StageVariables) == 0 && len(auth) == 0 {
+		return key
+	}
+
+	h := sha256.New()
+
+	names := make([]string, 0, len(input.StageVariables))
+	for name := range input.StageVariables {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		fmt.Fprintf(h, "%q=%q\n", name, input.StageVariables[name])
+	}
+
+	for _, value := range auth {
+		fmt.Fprintf(h, "authorization=%q\n", value)
+	}
+
+	return key + " " + hex.EncodeToString(h.Sum(nil))
+}
+
+// varyHash returns the hash of the request header values selected by the response Vary header,
+// empty when it names none.
+func varyHash(respHeader http.Header, reqHeaders map[string][]string) string {
+	var names []string
+
+	for _, value := range respHeader.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return ""
+	}
+
+	slices.Sort(names)
+
+	h := sha256.New()
+
+	for _, name := range names {
+		fmt.Fprintf(h, "%q=%q\n", name, http.Header(reqHeaders).Values(name))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func varyAll(h http.Header) bool {
+	for _, value := range h.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if strings.TrimSpace(name) == "*" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (c *CachedResponse) response(r *http.Request) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(c.StatusCode),
+		StatusCode:    c.StatusCode,
+		Proto:         r.Proto,
+		ProtoMajor:    r.ProtoMajor,
+		ProtoMinor:    r.ProtoMinor,
+		Header:        c.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       r,
+	}
+}
+
+// maxAge returns the freshness lifetime of a response, zero when it must be revalidated.
+func maxAge(h http.Header) time.Duration {
+	if hasCacheDirective(h, "no-cache") {
+		return 0
+	}
+
+	for _, directive := range cacheDirectives(h) {
+		name, value, _ := strings.Cut(directive, "=")
+		if name != "max-age" && name != "s-maxage" {
+			continue
+		}
+
+		if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+
+	return 0
+}
+
+func hasCacheDirective(h http.Header, directive string) bool {
+	for _, d := range cacheDirectives(h) {
+		if d == directive {
+			return true
+		}
+	}
+
+	return false
+}
+
+func cacheDirectives(h http.Header) []string {
+	var directives []string
+
+	for _, value := range h.Values("Cache-Control") {
+		for _, d := range strings.Split(value, ",") {
+			directives = append(directives, strings.ToLower(strings.TrimSpace(d)))
+		}
+	}
+
+	return directives
+}
+
+func cloneMultiValueHeaders(h map[string][]string) map[string][]string {
+	clone := make(map[string][]string, len(h)+1)
+
+	for name, values := range h {
+		clone[name] = values
+	}
+
+	return clone
+}
@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+)
+
+// StageGetter is implemented by clients able to get a stage, as [*apigateway.Client] does.
+type StageGetter interface {
+	GetStage(context.Context, *apigateway.GetStageInput, ...func(*apigateway.Options)) (*apigateway.GetStageOutput, error)
+}
+
+// stageClientCertificateID returns the client certificate configured in the stage.
+func stageClientCertificateID(ctx context.Context, cli ApiGwClient, apiID, stage string) (string, error) {
+	getter, ok := cli.(StageGetter)
+	if !ok {
+		return "", fmt.Errorf("%w: GetStage", ErrOperationNotSupported)
+	}
+
+	out, err := getter.GetStage(ctx, &apigateway.GetStageInput{
+		RestApiId: aws.String(apiID),
+		StageName: aws.String(stage),
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("get stage error: %w", err)
+	}
+
+	return aws.ToString(out.ClientCertificateId), nil
+}
+
+// WithClientCertificateID sets the client certificate used by every invoke,
+// for backends validating the API Gateway client certificate.
+func WithClientCertificateID(id string) Option {
+	return func(t *Transport) {
+		t.clientCertID = id
+	}
+}
+
+// WithClientCertificateFromStage discovers the client certificate configured in stage during
+// the initialization, the client must implement [StageGetter].
+// An explicit [WithClientCertificateID] takes precedence.
+func WithClientCertificateFromStage(stage string) Option {
+	return func(t *Transport) {
+		t.clientCertStage = stage
+	}
+}
@@ -0,0 +1,42 @@
+package transport
+
+import "time"
+
+// Clock abstracts the passage of time, so time dependent behavior
+// (latency measurement, slow-invoke warnings, refreshes, retries) can be simulated in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// WithClock sets the [Clock] used by the transport. Defaults to the system clock.
+func WithClock(c Clock) Option {
+	return func(t *Transport) {
+		t.clock = c
+	}
+}
+
+// WithSlowInvokeThreshold logs a warning for every invoke taking longer than d.
+func WithSlowInvokeThreshold(d time.Duration) Option {
+	return func(t *Transport) {
+		t.slowInvoke = d
+	}
+}
+
+// WithInvokeTimeout bounds every TestInvokeMethod call to d, timed out invokes
+// fail with an [*InvokeError] matching [ErrIntegrationTimeout].
+func WithInvokeTimeout(d time.Duration) Option {
+	return func(t *Transport) {
+		t.invokeTimeout = d
+	}
+}
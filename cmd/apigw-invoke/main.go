@@ -0,0 +1,256 @@
+// Command apigw-invoke calls the endpoints of a REST API through the transport,
+// printing the matched resource and the response.
+//
+// Usage:
+//
+//	apigw-invoke --api-id X [--profile p] [--region r] [-H 'Name: value'] [-d body|@file|-] METHOD PATH
+//	apigw-invoke diff --api-id X (--stage a --target-stage b | --target-api-id Y)
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+
+	transport "github.com/rcarrion2/aws-apigw-invoke-transport"
+)
+
+// errDiff is returned by the diff command when the routes differ, for the exit status.
+var errDiff = errors.New("routes differ")
+
+// newClient creates the API Gateway client, replaced in tests.
+var newClient = func(ctx context.Context, profile, region string) (transport.ApiGwClient, error) {
+	var opts []func(*config.LoadOptions) error
+
+	if profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	}
+
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config error: %w", err)
+	}
+
+	return apigateway.NewFromConfig(cfg), nil
+}
+
+func main() {
+	err := run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+
+	switch {
+	case err == nil:
+	case errors.Is(err, errDiff):
+		os.Exit(1)
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, "apigw-invoke:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) > 0 && args[0] == "diff" {
+		return runDiff(ctx, args[1:], stdout, stderr)
+	}
+
+	return runInvoke(ctx, args, stdin, stdout, stderr)
+}
+
+// headers is a repeatable Name: value flag.
+type headers http.Header
+
+func (h headers) String() string {
+	return fmt.Sprint(http.Header(h))
+}
+
+func (h headers) Set(value string) error {
+	name, v, found := strings.Cut(value, ":")
+	if !found {
+		return fmt.Errorf("header %q must have the Name: value form", value)
+	}
+
+	http.Header(h).Add(strings.TrimSpace(name), strings.TrimSpace(v))
+
+	return nil
+}
+
+// clientFlags are the flags shared by the commands.
+type clientFlags struct {
+	apiID   string
+	profile string
+	region  string
+}
+
+func (f *clientFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.apiID, "api-id", "", "REST API ID (required)")
+	fs.StringVar(&f.profile, "profile", "", "AWS shared config profile")
+	fs.StringVar(&f.region, "region", "", "AWS region, defaults to the profile one")
+}
+
+func runInvoke(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var (
+		cf      clientFlags
+		reqHdrs = headers{}
+	)
+
+	fs := flag.NewFlagSet("apigw-invoke", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	cf.register(fs)
+	fs.Var(reqHdrs, "H", "request header as 'Name: value', repeatable")
+	data := fs.String("d", "", "request body, @file to read it from a file or - from stdin")
+	verbose := fs.Bool("v", false, "log the transport debug messages to stderr")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if cf.apiID == "" || fs.NArg() != 2 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	method, path := strings.ToUpper(fs.Arg(0)), fs.Arg(1)
+
+	body, err := readBody(*data, stdin)
+	if err != nil {
+		return err
+	}
+
+	cli, err := newClient(ctx, cf.profile, cf.region)
+	if err != nil {
+		return err
+	}
+
+	var opts []transport.Option
+
+	if *verbose {
+		opts = append(opts, transport.WithLogger(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	}
+
+	t := transport.NewTransport(cli, cf.apiID, opts...)
+
+	req, err := http.NewRequestWithContext(ctx, method, "http://apigw-invoke"+path, body)
+	if err != nil {
+		return fmt.Errorf("create request error: %w", err)
+	}
+
+	req.Header = http.Header(reqHdrs)
+
+	resp, err := t.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if route, found := transport.RouteFromContext(resp.Request.Context()); found {
+		fmt.Fprintf(stdout, "resource: %s %s %s\n", route.ResourceID, route.Method, route.Template)
+	}
+
+	fmt.Fprintf(stdout, "status: %d %s\n", resp.StatusCode, http.StatusText(resp.StatusCode))
+
+	if err = resp.Header.Write(stdout); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout)
+
+	if _, err = io.Copy(stdout, resp.Body); err != nil {
+		return fmt.Errorf("read response body error: %w", err)
+	}
+
+	return nil
+}
+
+func readBody(data string, stdin io.Reader) (io.Reader, error) {
+	switch {
+	case data == "":
+		return http.NoBody, nil
+	case data == "-":
+		return stdin, nil
+	case strings.HasPrefix(data, "@"):
+		f, err := os.ReadFile(data[1:])
+		if err != nil {
+			return nil, fmt.Errorf("read body file error: %w", err)
+		}
+
+		return strings.NewReader(string(f)), nil
+	default:
+		return strings.NewReader(data), nil
+	}
+}
+
+func runDiff(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var cf clientFlags
+
+	fs := flag.NewFlagSet("apigw-invoke diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	cf.register(fs)
+	stage := fs.String("stage", "", "base stage, the live resources when empty")
+	targetStage := fs.String("target-stage", "", "target stage, the live resources when empty")
+	targetAPIID := fs.String("target-api-id", "", "target REST API ID, defaults to --api-id")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if cf.apiID == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	cli, err := newClient(ctx, cf.profile, cf.region)
+	if err != nil {
+		return err
+	}
+
+	source := func(apiID, stage string) transport.MappingSource {
+		if stage == "" {
+			return transport.ResourcesSource(cli, apiID)
+		}
+
+		return transport.StageSource(cli, apiID, stage)
+	}
+
+	if *targetAPIID == "" {
+		*targetAPIID = cf.apiID
+	}
+
+	diff, err := transport.CompareSources(ctx, source(cf.apiID, *stage), source(*targetAPIID, *targetStage))
+	if err != nil {
+		return err
+	}
+
+	for _, r := range diff.Added {
+		fmt.Fprintf(stdout, "+ %s %s\n", r.Method, r.Template)
+	}
+
+	for _, r := range diff.Removed {
+		fmt.Fprintf(stdout, "- %s %s\n", r.Method, r.Template)
+	}
+
+	for _, c := range diff.Changed {
+		fmt.Fprintf(stdout, "~ %s %s: required parameters %v -> %v\n",
+			c.Before.Method, c.Before.Template, c.Before.RequiredParameters, c.After.RequiredParameters)
+	}
+
+	if !diff.Empty() {
+		return errDiff
+	}
+
+	return nil
+}
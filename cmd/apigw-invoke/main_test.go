@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	transport "github.com/rcarrion2/aws-apigw-invoke-transport"
+)
+
+type fakeClient struct {
+	resources map[string][]types.Resource
+	input     *apigateway.TestInvokeMethodInput
+}
+
+func (c *fakeClient) TestInvokeMethod(
+	_ context.Context,
+	input *apigateway.TestInvokeMethodInput,
+	_ ...func(*apigateway.Options),
+) (*apigateway.TestInvokeMethodOutput, error) {
+	c.input = input
+
+	return &apigateway.TestInvokeMethodOutput{
+		Status:            http.StatusOK,
+		Body:              aws.String(`{"name":"john"}`),
+		MultiValueHeaders: map[string][]string{"Content-Type": {"application/json"}},
+	}, nil
+}
+
+func (c *fakeClient) GetResources(
+	_ context.Context,
+	input *apigateway.GetResourcesInput,
+	_ ...func(*apigateway.Options),
+) (*apigateway.GetResourcesOutput, error) {
+	return &apigateway.GetResourcesOutput{Items: c.resources[*input.RestApiId]}, nil
+}
+
+func (c *fakeClient) Options() apigateway.Options {
+	return apigateway.Options{Region: "us-east-1"}
+}
+
+func useClient(t *testing.T, cli *fakeClient) {
+	t.Helper()
+
+	original := newClient
+	newClient = func(context.Context, string, string) (transport.ApiGwClient, error) {
+		return cli, nil
+	}
+
+	t.Cleanup(func() { newClient = original })
+}
+
+func userResource(id string, methods ...string) types.Resource {
+	res := types.Resource{Id: aws.String(id), Path: aws.String("/api/v1/users/{name}"), ResourceMethods: map[string]types.Method{}}
+
+	for _, m := range methods {
+		res.ResourceMethods[m] = types.Method{}
+	}
+
+	return res
+}
+
+func TestRunInvoke(t *testing.T) {
+	// GIVEN
+	cli := &fakeClient{resources: map[string][]types.Resource{"abc123": {userResource("2cb3ff", http.MethodPut)}}}
+	useClient(t, cli)
+
+	var stdout, stderr bytes.Buffer
+
+	// WHEN
+	err := run(context.Background(),
+		[]string{"--api-id", "abc123", "-H", "X-Tenant: acme", "-d", `{"name":"john"}`, "put", "/api/v1/users/john"},
+		strings.NewReader(""), &stdout, &stderr)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, "resource: 2cb3ff PUT /api/v1/users/{name}\n"+
+		"status: 200 OK\n"+
+		"Content-Type: application/json\r\n"+
+		"\n"+
+		`{"name":"john"}`, stdout.String())
+
+	assert.Equal(t, `{"name":"john"}`, *cli.input.Body)
+	assert.Equal(t, []string{"acme"}, cli.input.MultiValueHeaders["X-Tenant"])
+}
+
+func TestRunDiff(t *testing.T) {
+	// GIVEN
+	useClient(t, &fakeClient{resources: map[string][]types.Resource{
+		"abc123": {userResource("2cb3ff", http.MethodGet)},
+		"def456": {userResource("9a8b7c", http.MethodGet, http.MethodDelete)},
+	}})
+
+	var stdout, stderr bytes.Buffer
+
+	// WHEN
+	err := run(context.Background(), []string{"diff", "--api-id", "abc123", "--target-api-id", "def456"},
+		strings.NewReader(""), &stdout, &stderr)
+
+	// THEN
+	assert.ErrorIs(t, err, errDiff)
+	assert.Equal(t, "+ DELETE /api/v1/users/{name}\n", stdout.String())
+}
@@ -0,0 +1,58 @@
+package transport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// decompressBody replaces a gzip or deflate encoded response body by the decoded one,
+// removing the Content-Encoding and Content-Length headers as [http.Transport] does.
+func decompressBody(resp *http.Response) error {
+	var newReader func(io.Reader) (io.ReadCloser, error)
+
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip":
+		newReader = func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) }
+	case "deflate":
+		newReader = zlib.NewReader
+	default:
+		return nil
+	}
+
+	encoded, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	reader, err := newReader(bytes.NewReader(encoded))
+	if err != nil {
+		return fmt.Errorf("decompress response error: %w", err)
+	}
+
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("decompress response error: %w", err)
+	}
+
+	resp.Header = resp.Header.Clone()
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.Body = io.NopCloser(bytes.NewReader(decoded))
+	resp.ContentLength = int64(len(decoded))
+	resp.Uncompressed = true
+
+	return nil
+}
+
+// WithResponseDecompression decodes gzip and deflate encoded response bodies,
+// which [http.Client] does not do for custom transports.
+func WithResponseDecompression() Option {
+	return func(t *Transport) {
+		t.decompress = true
+	}
+}
@@ -0,0 +1,84 @@
+package transport
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+)
+
+type routeContextKey struct{}
+
+// ContextWithRoute returns a copy of ctx carrying the matched route.
+func ContextWithRoute(ctx context.Context, route Route) context.Context {
+	return context.WithValue(ctx, routeContextKey{}, route)
+}
+
+// RouteFromContext returns the route matched by the transport, it is available in
+// the context given to the client invoke and in the request of the returned [http.Response]
+// (resp.Request.Context()), so wrapping round trippers and hooks can label their telemetry.
+func RouteFromContext(ctx context.Context) (Route, bool) {
+	route, ok := ctx.Value(routeContextKey{}).(Route)
+	return route, ok
+}
+
+type pathParametersContextKey struct{}
+
+// PathParametersFromContext returns the values of the path variables of the matched route template
+// (e.g. {"value": "john.doe"} for /api/v1/users/{value}), available where [RouteFromContext] is.
+func PathParametersFromContext(ctx context.Context) (map[string]string, bool) {
+	params, ok := ctx.Value(pathParametersContextKey{}).(map[string]string)
+	return params, ok
+}
+
+type resourceIDContextKey struct{}
+
+// ContextWithResourceID returns a copy of ctx pinning the resource invoked for the request,
+// matching is skipped, e.g. for ambiguous route templates. The request method is invoked.
+func ContextWithResourceID(ctx context.Context, resourceID string) context.Context {
+	return context.WithValue(ctx, resourceIDContextKey{}, resourceID)
+}
+
+func resourceIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(resourceIDContextKey{}).(string)
+	return id, ok && id != ""
+}
+
+type loggerContextKey struct{}
+
+// ContextWithLogger returns a copy of ctx carrying the logger the transport logs the request
+// activity with instead of its request logger, e.g. a request scoped logger with trace IDs.
+// The transport attributes (rest_api_id, stage and [WithLogAttrs]) are added to it.
+func ContextWithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
+
+type invokeLatencyContextKey struct{}
+
+// InvokeLatency returns the backend latency API Gateway reported for the invoke of resp,
+// which excludes the TestInvokeMethod call overhead. It is false for responses not
+// created by an invoke (e.g. stubbed or [WithResponseCache] cached responses).
+func InvokeLatency(resp *http.Response) (time.Duration, bool) {
+	if resp == nil || resp.Request == nil {
+		return 0, false
+	}
+
+	latency, ok := resp.Request.Context().Value(invokeLatencyContextKey{}).(time.Duration)
+	return latency, ok
+}
+
+type invokeOutputContextKey struct{}
+
+// OutputFromResponse returns the TestInvokeMethod output resp was created from, as AWS returned it
+// (e.g. with the Log and the headers before canonicalization). It is false for responses not
+// created by an invoke (e.g. cached responses). The output must not be modified.
+func OutputFromResponse(resp *http.Response) (*apigateway.TestInvokeMethodOutput, bool) {
+	if resp == nil || resp.Request == nil {
+		return nil, false
+	}
+
+	out, ok := resp.Request.Context().Value(invokeOutputContextKey{}).(*apigateway.TestInvokeMethodOutput)
+	return out, ok
+}
@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// RouteDiff is the difference between the routes of two stages or APIs.
+type RouteDiff struct {
+	// Added are the routes only present in the target.
+	Added []Route
+	// Removed are the routes only present in the base.
+	Removed []Route
+	// Changed are the routes present in both whose method settings differ.
+	Changed []RouteChange
+}
+
+// RouteChange is a route present in both sides of a [RouteDiff].
+type RouteChange struct {
+	Before Route
+	After  Route
+}
+
+// Empty reports whether both sides have the same routes.
+func (d RouteDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffRoutes compares the base routes with the target ones, by method and template.
+//
+// Resource IDs are not compared, since they differ between APIs. A route is changed when
+// its required parameters differ. Results are sorted by method#template.
+func DiffRoutes(base, target []Route) RouteDiff {
+	var (
+		diff        RouteDiff
+		baseByKey   = routesByKey(base)
+		targetByKey = routesByKey(target)
+	)
+
+	for key, after := range targetByKey {
+		before, exists := baseByKey[key]
+
+		switch {
+		case !exists:
+			diff.Added = append(diff.Added, after)
+		case !slices.Equal(before.RequiredParameters, after.RequiredParameters):
+			diff.Changed = append(diff.Changed, RouteChange{Before: before, After: after})
+		}
+	}
+
+	for key, before := range baseByKey {
+		if _, exists := targetByKey[key]; !exists {
+			diff.Removed = append(diff.Removed, before)
+		}
+	}
+
+	slices.SortFunc(diff.Added, compareRoutes)
+	slices.SortFunc(diff.Removed, compareRoutes)
+	slices.SortFunc(diff.Changed, func(a, b RouteChange) int { return compareRoutes(a.After, b.After) })
+
+	return diff
+}
+
+// CompareSources diffs the routes of two sources, e.g. two stages with [StageSource]
+// or two APIs with [ResourcesSource].
+func CompareSources(ctx context.Context, base, target MappingSource) (RouteDiff, error) {
+	baseRoutes, err := base.Routes(ctx)
+	if err != nil {
+		return RouteDiff{}, err
+	}
+
+	targetRoutes, err := target.Routes(ctx)
+	if err != nil {
+		return RouteDiff{}, err
+	}
+
+	return DiffRoutes(baseRoutes, targetRoutes), nil
+}
+
+func routesByKey(routes []Route) map[string]Route {
+	result := make(map[string]Route, len(routes))
+
+	for _, r := range routes {
+		result[endpointKey(r.Method, r.Template)] = r
+	}
+
+	return result
+}
+
+func compareRoutes(a, b Route) int {
+	return strings.Compare(endpointKey(a.Method, a.Template), endpointKey(b.Method, b.Template))
+}
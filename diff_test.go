@@ -0,0 +1,89 @@
+package transport_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rcarrion2/aws-apigw-invoke-transport"
+	"github.com/rcarrion2/aws-apigw-invoke-transport/transporttest"
+)
+
+func TestDiffRoutes(t *testing.T) {
+	// GIVEN
+	base := []transport.Route{
+		{Method: http.MethodGet, Template: "/api/v1/users/{value}", ResourceID: "a1"},
+		{Method: http.MethodDelete, Template: "/api/v1/users/{value}", ResourceID: "a1"},
+		{Method: http.MethodPost, Template: "/api/v1/users", ResourceID: "a2"},
+	}
+
+	target := []transport.Route{
+		{Method: http.MethodGet, Template: "/api/v1/users/{value}", ResourceID: "b1"},
+		{Method: http.MethodPost, Template: "/api/v1/users", ResourceID: "b2", RequiredParameters: []string{"header.X-Tenant-ID"}},
+		{Method: http.MethodGet, Template: "/api/v1/orders", ResourceID: "b3"},
+	}
+
+	// WHEN
+	diff := transport.DiffRoutes(base, target)
+
+	// THEN
+	assert.False(t, diff.Empty())
+	assert.Equal(t, []transport.Route{target[2]}, diff.Added)
+	assert.Equal(t, []transport.Route{base[1]}, diff.Removed)
+	assert.Equal(t, []transport.RouteChange{{Before: base[2], After: target[1]}}, diff.Changed)
+
+	assert.True(t, transport.DiffRoutes(base, base).Empty())
+}
+
+func TestCompareSources(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Twice()
+
+	for stage, deployment := range map[string]string{"dev": "d3v", "prod": "pr0d"} {
+		apiGwCli.
+			On("GetStage", mock.MatchedBy(func(i *apigateway.GetStageInput) bool { return *i.StageName == stage })).
+			Return(&apigateway.GetStageOutput{DeploymentId: aws.String(deployment)}, nil).
+			Once()
+	}
+
+	apiGwCli.
+		On("GetDeployment", mock.MatchedBy(func(i *apigateway.GetDeploymentInput) bool { return *i.DeploymentId == "pr0d" })).
+		Return(&apigateway.GetDeploymentOutput{ApiSummary: map[string]map[string]types.MethodSnapshot{
+			"/api/v1/users/{value}": {"GET": {}},
+		}}, nil).
+		Once()
+
+	apiGwCli.
+		On("GetDeployment", mock.MatchedBy(func(i *apigateway.GetDeploymentInput) bool { return *i.DeploymentId == "d3v" })).
+		Return(&apigateway.GetDeploymentOutput{ApiSummary: map[string]map[string]types.MethodSnapshot{
+			"/api/v1/users/{value}": {"GET": {}, "DELETE": {}},
+		}}, nil).
+		Once()
+
+	// WHEN
+	diff, err := transport.CompareSources(context.Background(),
+		transport.StageSource(apiGwCli, apiID, "prod"),
+		transport.StageSource(apiGwCli, apiID, "dev"))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, []transport.Route{{Method: "DELETE", Template: "/api/v1/users/{value}", ResourceID: "2cb3ff"}}, diff.Added)
+	assert.Empty(t, diff.Removed)
+	assert.Empty(t, diff.Changed)
+
+	apiGwCli.AssertExpectations(t)
+}
@@ -0,0 +1,101 @@
+package transport
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// DirectFallback sends the requests TestInvokeMethod can not handle to the real endpoint, signed with SigV4
+// for the execute-api service, see [WithDirectFallback]. Zero fields take the client configuration.
+type DirectFallback struct {
+	// Transport sends the signed requests, [http.DefaultTransport] when nil.
+	Transport http.RoundTripper
+	// Credentials sign the requests, the client ones when nil.
+	Credentials aws.CredentialsProvider
+	// Region signs the requests, the client one when empty.
+	Region string
+	// Select sends other requests directly too (e.g. by path).
+	Select func(r *http.Request) bool
+}
+
+// WithDirectFallback sends the requests TestInvokeMethod can not handle to their URL (the invoke URL or
+// the custom domain) signed with SigV4, instead of failing them: protocol upgrades (e.g. websockets),
+// bodies larger than the [WithMaxBodySize] limit and the requests chosen by fb.Select.
+// Direct requests are not matched nor intercepted.
+func WithDirectFallback(fb DirectFallback) Option {
+	return func(t *Transport) {
+		t.direct = &fb
+	}
+}
+
+// sendsDirect reports whether r is sent with the direct fallback, bodies of unknown length are
+// checked once buffered.
+func (t *Transport) sendsDirect(r *http.Request) bool {
+	if t.direct == nil {
+		return false
+	}
+
+	if r.Header.Get("Upgrade") != "" {
+		return true
+	}
+
+	if t.maxBodySize > 0 && r.ContentLength > t.maxBodySize {
+		return true
+	}
+
+	return t.direct.Select != nil && t.direct.Select(r)
+}
+
+// directRoundTrip signs r and sends it to its URL.
+func (t *Transport) directRoundTrip(r *http.Request, log *slog.Logger) (*http.Response, error) {
+	ctx := r.Context()
+
+	r, body, err := bufferBody(r)
+	if err != nil {
+		return nil, fmt.Errorf("direct fallback error: %w", err)
+	}
+
+	direct := r.Clone(ctx)
+	direct.Body = r.Body
+	direct.RequestURI = ""
+
+	options := t.client.Options()
+
+	creds := t.direct.Credentials
+	if creds == nil {
+		creds = options.Credentials
+	}
+
+	if creds == nil {
+		return nil, fmt.Errorf("direct fallback error: %w", ErrNoCredentials)
+	}
+
+	credentials, err := creds.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("direct fallback error: %w", err)
+	}
+
+	payloadHash := sha256.Sum256(body)
+	region := cmp.Or(t.direct.Region, options.Region)
+
+	err = v4.NewSigner().SignHTTP(ctx, credentials, direct, hex.EncodeToString(payloadHash[:]), "execute-api", region, t.clock.Now())
+	if err != nil {
+		return nil, fmt.Errorf("direct fallback error: %w", err)
+	}
+
+	log.DebugContext(ctx, "direct fallback", slog.String("method", r.Method), slog.String("url", r.URL.String()))
+
+	rt := t.direct.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	return rt.RoundTrip(direct)
+}
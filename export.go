@@ -0,0 +1,242 @@
+package transport
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+
+	"github.com/rcarrion2/aws-apigw-invoke-transport/internal/routetemplate"
+)
+
+// ExportGetter is implemented by clients able to export the API of a stage, as [*apigateway.Client] does.
+type ExportGetter interface {
+	GetExport(context.Context, *apigateway.GetExportInput, ...func(*apigateway.Options)) (*apigateway.GetExportOutput, error)
+}
+
+type exportSource struct {
+	client ApiGwClient
+	apiID  string
+	stage  string
+}
+
+// ExportSource is a [MappingSource] of the routes deployed to a stage, taken from the OpenAPI export
+// of the stage, so routing matches the deployed stage and not the current resources. Resource IDs
+// come from the live API resources, the deployed routes of deleted resources are skipped.
+//
+// The client must implement [ExportGetter].
+func ExportSource(client ApiGwClient, apiID, stage string) MappingSource {
+	return exportSource{client: client, apiID: apiID, stage: stage}
+}
+
+func (s exportSource) Name() string {
+	return "export:" + s.stage
+}
+
+// openAPIAnyMethod is the API Gateway extension of the ANY method in the exported paths.
+const openAPIAnyMethod = "x-amazon-apigateway-any-method"
+
+type openAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required"`
+	Schema   *openAPISchema `json:"schema,omitempty"`
+}
+
+type openAPISchema struct {
+	Type string `json:"type"`
+}
+
+type openAPIResponse struct {
+	Description string `json:"description"`
+}
+
+type openAPIOperation struct {
+	Parameters []openAPIParameter         `json:"parameters,omitempty"`
+	Responses  map[string]openAPIResponse `json:"responses,omitempty"`
+}
+
+type openAPIExport struct {
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+func (s exportSource) Routes(ctx context.Context) ([]Route, error) {
+	getter, ok := s.client.(ExportGetter)
+	if !ok {
+		return nil, fmt.Errorf("%w: GetExport", ErrOperationNotSupported)
+	}
+
+	out, err := getter.GetExport(ctx, &apigateway.GetExportInput{
+		RestApiId:  aws.String(s.apiID),
+		StageName:  aws.String(s.stage),
+		ExportType: aws.String("oas30"),
+		Accepts:    aws.String("application/json"),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("get export error: %w", err)
+	}
+
+	var export openAPIExport
+	if err := json.Unmarshal(out.Body, &export); err != nil {
+		return nil, fmt.Errorf("decode export error: %w", err)
+	}
+
+	live, err := ResourcesSource(s.client, s.apiID).Routes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceIDs := make(map[string]string, len(live))
+	for _, route := range live {
+		resourceIDs[route.Template] = route.ResourceID
+	}
+
+	var routes []Route
+
+	for template, item := range export.Paths {
+		resourceID, found := resourceIDs[template]
+		if !found {
+			continue
+		}
+
+		for key, raw := range item {
+			method, isMethod := openAPIMethod(key)
+			if !isMethod {
+				continue
+			}
+
+			var op openAPIOperation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("decode export error: %s %s: %w", method, template, err)
+			}
+
+			routes = append(routes, Route{
+				Method:             method,
+				Template:           template,
+				ResourceID:         resourceID,
+				RequiredParameters: op.requiredParameters(),
+			})
+		}
+	}
+
+	return routes, nil
+}
+
+// openAPIMethod returns the method of a path item key, false for the other fields (e.g. parameters).
+func openAPIMethod(key string) (string, bool) {
+	if key == openAPIAnyMethod {
+		return anyMethod, true
+	}
+
+	method := strings.ToUpper(key)
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method, true
+	default:
+		return "", false
+	}
+}
+
+// openAPILocations are the method request parameter locations of the OpenAPI parameter locations.
+var openAPILocations = map[string]string{"query": "querystring", "header": "header", "path": "path"}
+
+func (op openAPIOperation) requiredParameters() []string {
+	var params []string
+
+	for _, p := range op.Parameters {
+		if location, found := openAPILocations[p.In]; found && p.Required {
+			params = append(params, location+"."+p.Name)
+		}
+	}
+
+	slices.Sort(params)
+
+	return params
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIDocument struct {
+	OpenAPI string                                 `json:"openapi"`
+	Info    openAPIInfo                            `json:"info"`
+	Paths   map[string]map[string]openAPIOperation `json:"paths"`
+}
+
+// ExportOpenAPI writes the mapped routes to w as a minimal OpenAPI 3 document, with the paths, methods
+// and parameters (path variables and required parameters), mapping the API first if needed.
+// The ANY method is written as the x-amazon-apigateway-any-method extension, as API Gateway exports do.
+func (t *Transport) ExportOpenAPI(w io.Writer) error {
+	if err := t.initMappings(context.Background()); err != nil {
+		return err
+	}
+
+	doc := openAPIDocument{
+		OpenAPI: "3.0.1",
+		Info:    openAPIInfo{Title: t.apiID, Version: cmp.Or(t.stage, "1.0")},
+		Paths:   map[string]map[string]openAPIOperation{},
+	}
+
+	for _, r := range t.currentMapping() {
+		item, found := doc.Paths[r.path]
+		if !found {
+			item = map[string]openAPIOperation{}
+			doc.Paths[r.path] = item
+		}
+
+		method := strings.ToLower(r.method)
+		if r.method == anyMethod {
+			method = openAPIAnyMethod
+		}
+
+		item[method] = openAPIOperation{
+			Parameters: openAPIParameters(r),
+			Responses:  map[string]openAPIResponse{"default": {Description: "Integration response"}},
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encode openapi error: %w", err)
+	}
+
+	return nil
+}
+
+// openAPIParameters returns the path variables of the resource, then its other required parameters.
+func openAPIParameters(r resource) []openAPIParameter {
+	var params []openAPIParameter
+
+	for _, segment := range strings.Split(r.path, "/") {
+		if routetemplate.SegmentKind(segment) != routetemplate.Literal {
+			name := routetemplate.VarName(segment)
+			params = append(params, openAPIParameter{Name: name, In: "path", Required: true, Schema: &openAPISchema{Type: "string"}})
+		}
+	}
+
+	for _, param := range r.requiredParams {
+		location, name, _ := strings.Cut(param, ".")
+
+		for in, l := range openAPILocations {
+			if l == location && in != "path" {
+				params = append(params, openAPIParameter{Name: name, In: in, Required: true, Schema: &openAPISchema{Type: "string"}})
+			}
+		}
+	}
+
+	return params
+}
@@ -0,0 +1,136 @@
+package transport
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+)
+
+// Fault is injected into the requests matching a [WithFault] pattern, to test how clients behave
+// with slow or failing routes without touching the backend.
+type Fault struct {
+	// Probability of injecting the fault into a matching request, from 0 (never) to 1 (always).
+	// Nil injects it always.
+	Probability *float64
+
+	// Latency delays the request, before either invoking or the forced response.
+	Latency time.Duration
+	// StatusCode is responded with, with an empty body, instead of invoking the method.
+	StatusCode int
+	// Drop invokes the method and drops its response, failing with an [InvokeError] wrapping
+	// [ErrResponseDropped] as a lost connection would.
+	Drop bool
+}
+
+type fault struct {
+	routePattern
+	Fault
+}
+
+type faults struct {
+	mu     sync.Mutex
+	rand   *rand.Rand // nil uses the top-level source, see [WithFaultSeed].
+	faults []fault
+}
+
+// pick returns the first fault matching the request which is rolled to be injected.
+func (fs *faults) pick(method, path string) (Fault, bool) {
+	for _, f := range fs.faults {
+		if f.match(method, path) && (f.Probability == nil || fs.roll() < *f.Probability) {
+			return f.Fault, true
+		}
+	}
+
+	return Fault{}, false
+}
+
+func (fs *faults) roll() float64 {
+	if fs.rand == nil {
+		return rand.Float64()
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return fs.rand.Float64()
+}
+
+// WithFault injects f into the requests matching pattern, it can be used several times.
+// When several faults match a request, the first one rolled to be injected is used.
+//
+// The pattern has the method#path form (e.g. GET#/api/v1/users/{value}).
+func WithFault(pattern string, f Fault) Option {
+	p := newRoutePattern(pattern)
+
+	return func(t *Transport) {
+		if t.faults == nil {
+			t.faults = &faults{}
+		}
+
+		t.faults.faults = append(t.faults.faults, fault{routePattern: p, Fault: f})
+	}
+}
+
+// WithFaultSeed seeds the [WithFault] probability rolls, so fault sequences can be reproduced.
+func WithFaultSeed(seed uint64) Option {
+	return func(t *Transport) {
+		if t.faults == nil {
+			t.faults = &faults{}
+		}
+
+		t.faults.rand = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
+// faulting wraps next with the [WithFault] injection.
+func (t *Transport) faulting(next Invoker, res resource, path string) Invoker {
+	if t.faults == nil || len(t.faults.faults) == 0 {
+		return next
+	}
+
+	return InvokerFunc(func(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error) {
+		f, inject := t.faults.pick(r.Method, path)
+		if !inject {
+			return next.Invoke(r, input)
+		}
+
+		if f.Latency > 0 {
+			select {
+			case <-r.Context().Done():
+				return nil, newInvokeError(aws.ToString(input.RestApiId), res, r.Method,
+					aws.ToString(input.PathWithQueryString), r.Context().Err())
+			case <-t.clock.After(f.Latency):
+			}
+		}
+
+		switch {
+		case f.StatusCode != 0:
+			return &http.Response{
+				Status:     http.StatusText(f.StatusCode),
+				StatusCode: f.StatusCode,
+				Proto:      r.Proto,
+				ProtoMajor: r.ProtoMajor,
+				ProtoMinor: r.ProtoMinor,
+				Header:     http.Header{},
+				Body:       http.NoBody,
+				Request:    r,
+			}, nil
+		case f.Drop:
+			resp, err := next.Invoke(r, input)
+			if err != nil {
+				return nil, err
+			}
+
+			_ = resp.Body.Close()
+
+			return nil, newInvokeError(aws.ToString(input.RestApiId), res, r.Method,
+				aws.ToString(input.PathWithQueryString), ErrResponseDropped)
+		default:
+			return next.Invoke(r, input)
+		}
+	})
+}
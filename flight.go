@@ -0,0 +1,91 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// flightGroup deduplicates the concurrent fetches of a key, as golang.org/x/sync/singleflight does,
+// so the fetches run outside the locks guarding their results.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done chan struct{}
+	val  any
+	err  error
+}
+
+// fetchOnce returns the value of key found by lookup, or else the one fetched by fetch. Concurrent
+// callers of a key share its fetch, each one stops waiting when its ctx is done. The fetch must
+// publish the value it returns for lookup, failed fetches are not kept and the next caller fetches again.
+func fetchOnce[T any](
+	ctx context.Context,
+	g *flightGroup,
+	key string,
+	lookup func() (T, bool),
+	fetch func() (T, error),
+) (T, error) {
+	if val, found := lookup(); found {
+		return val, nil
+	}
+
+	for {
+		g.mu.Lock()
+
+		call, inFlight := g.calls[key]
+		if !inFlight {
+			break
+		}
+
+		g.mu.Unlock()
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+
+		if call.err == nil {
+			val, _ := call.val.(T)
+			return val, nil
+		}
+
+		// the fetch was canceled with the context of its caller, not with this one: fetch again
+		if !errors.Is(call.err, context.Canceled) && !errors.Is(call.err, context.DeadlineExceeded) {
+			var zero T
+			return zero, call.err
+		}
+	}
+
+	// the value is published before the call is removed, so it is found once no call is in flight
+	if val, found := lookup(); found {
+		g.mu.Unlock()
+		return val, nil
+	}
+
+	if g.calls == nil {
+		g.calls = map[string]*flightCall{}
+	}
+
+	call := &flightCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+
+		close(call.done)
+	}()
+
+	val, err := fetch()
+	call.val, call.err = val, err
+
+	return val, err
+}
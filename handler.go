@@ -0,0 +1,69 @@
+package transport
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+type handler struct {
+	t *Transport
+}
+
+// NewHandler returns a [http.Handler] serving requests through t, e.g. to run a local server
+// in front of a private API. Transport errors are answered with the status API Gateway
+// would respond with when known (400, 413), 404 for unmatched requests, 504 for timeouts,
+// 503 for open circuits and 502 otherwise.
+func NewHandler(t *Transport) http.Handler {
+	return handler{t: t}
+}
+
+func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	out.URL.Host = r.Host
+
+	if out.URL.Scheme == "" {
+		out.URL.Scheme = "http"
+	}
+
+	resp, err := h.t.RoundTrip(out)
+	if err != nil {
+		h.t.requestLog(r.Context()).DebugContext(r.Context(), "handler round trip error", slog.Any("error", err))
+		http.Error(w, err.Error(), errorStatus(err))
+
+		return
+	}
+
+	defer resp.Body.Close()
+
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+
+	w.WriteHeader(resp.StatusCode)
+
+	if _, err = io.Copy(w, resp.Body); err != nil {
+		h.t.requestLog(r.Context()).DebugContext(r.Context(), "handler write error", slog.Any("error", err))
+	}
+}
+
+func errorStatus(err error) int {
+	var statusErr interface{ StatusCode() int }
+
+	switch {
+	case errors.As(err, &statusErr):
+		return statusErr.StatusCode()
+	case errors.Is(err, ErrInvalidMultipart):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrResourceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrIntegrationTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, ErrCircuitOpen):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadGateway
+	}
+}
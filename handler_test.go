@@ -0,0 +1,64 @@
+package transport_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rcarrion2/aws-apigw-invoke-transport"
+	"github.com/rcarrion2/aws-apigw-invoke-transport/transporttest"
+)
+
+func TestNewHandler(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+			return *i.ResourceId == "8143a9" && *i.Body == `{"name":"john.doe"}` && *i.PathWithQueryString == "/api/v1/users?dry=1"
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{
+			Body:              aws.String(`{"id":1}`),
+			Status:            http.StatusCreated,
+			MultiValueHeaders: map[string][]string{"Content-Type": {"application/json"}},
+		}, nil).
+		Once()
+
+	srv := httptest.NewServer(transport.NewHandler(transport.NewTransport(apiGwCli, apiID)))
+	defer srv.Close()
+
+	// WHEN
+	createdResp, err := http.Post(srv.URL+"/api/v1/users?dry=1", "application/json", strings.NewReader(`{"name":"john.doe"}`))
+	require.NoError(t, err)
+
+	defer createdResp.Body.Close()
+
+	notFoundResp, err := http.Get(srv.URL + "/not/found")
+	require.NoError(t, err)
+
+	defer notFoundResp.Body.Close()
+
+	// THEN
+	assert.Equal(t, http.StatusCreated, createdResp.StatusCode)
+	assert.Equal(t, "application/json", createdResp.Header.Get("Content-Type"))
+	assert.Equal(t, `{"id":1}`, readString(createdResp.Body))
+
+	assert.Equal(t, http.StatusNotFound, notFoundResp.StatusCode)
+	assert.Equal(t, "resource not found: GET /not/found\n", readString(notFoundResp.Body))
+
+	apiGwCli.AssertExpectations(t)
+}
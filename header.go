@@ -0,0 +1,65 @@
+package transport
+
+import (
+	"net/http"
+	"strings"
+)
+
+// HeaderFilter selects the request headers forwarded to the invoke, see [WithHeaderFilter].
+// Names are case-insensitive, a name ending with * matches by prefix (e.g. X-Forwarded-*).
+type HeaderFilter struct {
+	// Allow forwards only the matching headers, all headers are allowed when empty.
+	Allow []string
+	// Deny drops the matching headers, it has precedence over Allow.
+	Deny []string
+	// Transform is called with the filtered headers, which it can change in place.
+	Transform func(http.Header)
+}
+
+// apply returns the filtered copy of h, h is not changed.
+func (f *HeaderFilter) apply(h http.Header) http.Header {
+	if f == nil {
+		return h
+	}
+
+	filtered := make(http.Header, len(h))
+
+	for name, values := range h {
+		if (len(f.Allow) == 0 || matchHeader(f.Allow, name)) && !matchHeader(f.Deny, name) {
+			filtered[name] = values
+		}
+	}
+
+	filtered = filtered.Clone()
+
+	if f.Transform != nil {
+		f.Transform(filtered)
+	}
+
+	return filtered
+}
+
+func matchHeader(names []string, name string) bool {
+	name = http.CanonicalHeaderKey(name)
+
+	for _, n := range names {
+		if prefix, isPrefix := strings.CutSuffix(n, "*"); isPrefix {
+			if strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix)) {
+				return true
+			}
+		} else if http.CanonicalHeaderKey(n) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithHeaderFilter filters the request headers before they are forwarded in the invoke input,
+// e.g. to stop forwarding the Authorization of local tooling. Parameters validation
+// (see [WithRequestParametersValidation]) checks the unfiltered headers.
+func WithHeaderFilter(f HeaderFilter) Option {
+	return func(t *Transport) {
+		t.headerFilter = &f
+	}
+}
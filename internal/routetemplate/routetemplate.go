@@ -0,0 +1,72 @@
+// Package routetemplate holds the route template rules shared by the REST API and the HTTP API transports:
+// the path variables of the templates (e.g. /users/{id} or /files/{proxy+}), the regexes matching them
+// and the precedence of the templates matching the same request.
+package routetemplate
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Kind is the kind of a template segment, the kinds are in precedence order.
+type Kind int
+
+const (
+	Literal Kind = iota
+	Var
+	Greedy
+)
+
+// SegmentKind returns the kind of a template segment.
+func SegmentKind(segment string) Kind {
+	switch {
+	case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "+}"):
+		return Greedy
+	case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+		return Var
+	default:
+		return Literal
+	}
+}
+
+// VarName returns the variable name of a variable segment (e.g. proxy for {proxy+}).
+func VarName(segment string) string {
+	return strings.TrimSuffix(strings.Trim(segment, "{}"), "+")
+}
+
+// Precedes reports whether the template has precedence over other when both match a request.
+// Templates are compared segment by segment: literal segments precede path variables,
+// which precede greedy variables. Longer templates precede shorter ones and
+// the remaining ties are broken by template, so matching does not depend on map order.
+func Precedes(template, other string) bool {
+	segments, otherSegments := strings.Split(template, "/"), strings.Split(other, "/")
+
+	for i := 0; i < min(len(segments), len(otherSegments)); i++ {
+		if kind, otherKind := SegmentKind(segments[i]), SegmentKind(otherSegments[i]); kind != otherKind {
+			return kind < otherKind
+		}
+	}
+
+	if len(segments) != len(otherSegments) {
+		return len(segments) > len(otherSegments)
+	}
+
+	return template < other
+}
+
+var (
+	// greedyVarRegex matches a quoted greedy path variable (e.g. {proxy+}).
+	greedyVarRegex = regexp.MustCompile(`\\\{[^/]+\\\+\\}`)
+	// varRegex matches a quoted path variable (e.g. {value}).
+	varRegex = regexp.MustCompile(`\\{[^/]+}`)
+)
+
+// Regex compiles the regex matching key, a template prefixed by its method (e.g. GET /users/{id}),
+// with a group per path variable.
+func Regex(key string) (*regexp.Regexp, error) {
+	pattern := regexp.QuoteMeta(key)
+	pattern = greedyVarRegex.ReplaceAllString(pattern, `(.+)`) // greedy variables can span several segments
+	pattern = varRegex.ReplaceAllString(pattern, `([^/]+)`)
+
+	return regexp.Compile("^" + pattern + "$")
+}
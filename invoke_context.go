@@ -0,0 +1,192 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+)
+
+var (
+	stageNameRegex    = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	stageVarNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+)
+
+// InvokeContext bundles the per-request overrides of a single invoke, see [NewInvokeContext].
+// Zero fields leave the transport configuration untouched.
+type InvokeContext struct {
+	// APIID invokes another REST API, whose resources are mapped on first use.
+	APIID string
+	// Stage simulates the stage taking its stage variables (the client must implement [StageGetter]).
+	Stage string
+	// StageVariables are set by name, over the transport and Stage ones.
+	StageVariables map[string]string
+	// Credentials sign the invoke instead of the client credentials.
+	Credentials aws.CredentialsProvider
+	// Headers are set in the invoke input, replacing the request ones with the same name.
+	Headers http.Header
+}
+
+type invokeContextKey struct{}
+
+// NewInvokeContext returns a copy of ctx carrying per-request overrides.
+// The overrides are validated and applied all together by [Transport.RoundTrip],
+// an invalid InvokeContext fails the request with [ErrInvalidInvokeContext].
+func NewInvokeContext(ctx context.Context, ic InvokeContext) context.Context {
+	return context.WithValue(ctx, invokeContextKey{}, ic)
+}
+
+// InvokeContextFromContext returns the overrides set with [NewInvokeContext].
+func InvokeContextFromContext(ctx context.Context) (InvokeContext, bool) {
+	ic, ok := ctx.Value(invokeContextKey{}).(InvokeContext)
+	return ic, ok
+}
+
+func (ic InvokeContext) validate(cli ApiGwClient) error {
+	if ic.Stage != "" {
+		if !stageNameRegex.MatchString(ic.Stage) {
+			return fmt.Errorf("%w: invalid stage name %q", ErrInvalidInvokeContext, ic.Stage)
+		}
+
+		if _, ok := cli.(StageGetter); !ok {
+			return fmt.Errorf("%w: stage requires a client implementing StageGetter", ErrInvalidInvokeContext)
+		}
+	}
+
+	for name := range ic.StageVariables {
+		if !stageVarNameRegex.MatchString(name) {
+			return fmt.Errorf("%w: invalid stage variable name %q", ErrInvalidInvokeContext, name)
+		}
+	}
+
+	return nil
+}
+
+// apiMapping returns the mapping of another API, built on first use.
+func (t *Transport) apiMapping(ctx context.Context, apiID string) (resourceMapping, error) {
+	return t.fetchMapping(ctx, apiID, func() (resourceMapping, error) {
+		return buildMapping(ctx, []MappingSource{t.configured(t.resourcesSource(apiID))}, t.initLog, t.strictSources)
+	})
+}
+
+// fetchMapping returns the API (or API stage) mapping of key, built by build on first use.
+func (t *Transport) fetchMapping(ctx context.Context, key string, build func() (resourceMapping, error)) (resourceMapping, error) {
+	lookup := func() (resourceMapping, bool) {
+		t.overridesMu.Lock()
+		defer t.overridesMu.Unlock()
+
+		mapping, found := t.apiMappings[key]
+
+		return mapping, found
+	}
+
+	return fetchOnce(ctx, &t.overridesFlights, "mapping "+key, lookup, func() (resourceMapping, error) {
+		mapping, err := build()
+		if err != nil {
+			return nil, err
+		}
+
+		t.overridesMu.Lock()
+		defer t.overridesMu.Unlock()
+
+		t.apiMappings[key] = mapping
+
+		return mapping, nil
+	})
+}
+
+// stageVariablesOf returns the variables of a stage, fetched on first use.
+func (t *Transport) stageVariablesOf(ctx context.Context, apiID, stage string) (map[string]string, error) {
+	key := apiID + "/" + stage
+
+	lookup := func() (map[string]string, bool) {
+		t.overridesMu.Lock()
+		defer t.overridesMu.Unlock()
+
+		vars, found := t.stagesVariables[key]
+
+		return vars, found
+	}
+
+	return fetchOnce(ctx, &t.overridesFlights, "stage "+key, lookup, func() (map[string]string, error) {
+		out, err := t.client.(StageGetter).GetStage(ctx, &apigateway.GetStageInput{
+			RestApiId: aws.String(apiID),
+			StageName: aws.String(stage),
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("get stage error: %w", err)
+		}
+
+		t.overridesMu.Lock()
+		defer t.overridesMu.Unlock()
+
+		t.stagesVariables[key] = out.Variables
+
+		return out.Variables, nil
+	})
+}
+
+// applyInvokeContext applies the overrides to the invoke input, returning the client options to use.
+func (t *Transport) applyInvokeContext(
+	ctx context.Context,
+	ic InvokeContext,
+	input *apigateway.TestInvokeMethodInput,
+) ([]func(*apigateway.Options), error) {
+	vars := maps.Clone(input.StageVariables)
+
+	if ic.Stage != "" {
+		stageVars, err := t.stageVariablesOf(ctx, *input.RestApiId, ic.Stage)
+		if err != nil {
+			return nil, err
+		}
+
+		vars = merge(vars, stageVars)
+
+		if t.servesStage(ic.Stage) {
+			vars = merge(vars, t.stagesOverrides[ic.Stage])
+		}
+	}
+
+	vars = merge(vars, ic.StageVariables)
+
+	if len(ic.Headers) > 0 {
+		headers := http.Header(input.MultiValueHeaders).Clone()
+
+		for name, values := range ic.Headers {
+			headers[http.CanonicalHeaderKey(name)] = values
+		}
+
+		input.MultiValueHeaders = headers
+	}
+
+	input.StageVariables = vars
+
+	var optFns []func(*apigateway.Options)
+
+	if ic.Credentials != nil {
+		optFns = append(optFns, func(o *apigateway.Options) {
+			o.Credentials = ic.Credentials
+		})
+	}
+
+	return optFns, nil
+}
+
+func merge(dst, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+
+	if dst == nil {
+		dst = make(map[string]string, len(src))
+	}
+
+	maps.Copy(dst, src)
+
+	return dst
+}
@@ -0,0 +1,54 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// InvokeError is returned when the TestInvokeMethod call fails.
+type InvokeError struct {
+	APIID      string
+	ResourceID string
+	Method     string
+	// Path is the invoked path, with the query string.
+	Path string
+
+	// StatusCode is the status API Gateway responded the call with, zero when no response was received
+	// (e.g. a network error or a canceled context).
+	StatusCode int
+
+	// Err is the SDK error.
+	Err error
+}
+
+func newInvokeError(apiID string, res resource, method, path string, err error) *InvokeError {
+	return &InvokeError{
+		APIID:      apiID,
+		ResourceID: res.id,
+		Method:     method,
+		Path:       path,
+		StatusCode: statusCodeOf(err),
+		Err:        err,
+	}
+}
+
+func (e *InvokeError) Error() string {
+	return fmt.Sprintf("invoke error: %s", e.Err)
+}
+
+func (e *InvokeError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports whether the invoke timed out, either bounded by [WithInvokeTimeout] or the
+// caller deadline, or because API Gateway responded with 504 Gateway Timeout.
+func (e *InvokeError) Timeout() bool {
+	return errors.Is(e.Err, context.DeadlineExceeded) || e.StatusCode == http.StatusGatewayTimeout
+}
+
+// Is matches [ErrIntegrationTimeout] and [context.DeadlineExceeded] for timed out invokes.
+func (e *InvokeError) Is(target error) bool {
+	return (target == ErrIntegrationTimeout || target == context.DeadlineExceeded) && e.Timeout()
+}
@@ -0,0 +1,85 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+)
+
+// Invoker invokes the API Gateway method matched by a request with the given input.
+// The default one calls TestInvokeMethod, see [WithInvoker] to send the requests elsewhere.
+type Invoker interface {
+	Invoke(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error)
+}
+
+// InvokerFunc is a function [Invoker].
+type InvokerFunc func(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error)
+
+func (f InvokerFunc) Invoke(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error) {
+	return f(r, input)
+}
+
+type routeInvoker struct {
+	patterns routePatterns
+	invoker  Invoker
+}
+
+// WithInvoker sends the requests of the routes matching any of the patterns, all the routes when none
+// is given, through inv instead of TestInvokeMethod, e.g. a SigV4 HTTP client, recorded responses or
+// a fake. The interceptors and the transport features (retries, circuit breaker, cache...) still wrap
+// it, the request context carries the matched route (see [RouteFromContext]). The invoke logs, metrics,
+// stats and response modifiers of the TestInvokeMethod invokes are up to inv.
+//
+// It can be used several times, the first matching invoker is used. Patterns have the method#path form
+// of the route templates (e.g. GET#/api/v1/users/{value}).
+func WithInvoker(inv Invoker, patterns ...string) Option {
+	ps := make(routePatterns, 0, len(patterns))
+	for _, pattern := range patterns {
+		ps = append(ps, newRoutePattern(pattern))
+	}
+
+	return func(t *Transport) {
+		t.invokers = append(t.invokers, routeInvoker{patterns: ps, invoker: inv})
+	}
+}
+
+// routeInvoker returns the [WithInvoker] invoker of the resource, matched with the request method.
+func (t *Transport) routeInvoker(method string, res resource) (Invoker, bool) {
+	for _, ri := range t.invokers {
+		if len(ri.patterns) == 0 || ri.patterns.match(method, res.path) {
+			return ri.invoker, true
+		}
+	}
+
+	return nil, false
+}
+
+// Interceptor wraps an [Invoker], e.g. to change the invoke input, the response, or assert on them.
+type Interceptor func(next Invoker) Invoker
+
+// WithInterceptor adds interceptors around every invoke, the first one is the outermost.
+// Interceptors run once a resource is matched and the invoke input created,
+// the request context carries the matched route (see [RouteFromContext]).
+func WithInterceptor(interceptors ...Interceptor) Option {
+	return func(t *Transport) {
+		t.interceptors = append(t.interceptors, interceptors...)
+	}
+}
+
+// WithInputModifier adds a hook changing the invoke input right before TestInvokeMethod is called,
+// e.g. to set headers or the ClientCertificateId per request. Modifiers run in order on every
+// attempt (see [WithRetry]), an error fails the request without invoking.
+func WithInputModifier(modify func(ctx context.Context, input *apigateway.TestInvokeMethodInput) error) Option {
+	return func(t *Transport) {
+		t.inputModifiers = append(t.inputModifiers, modify)
+	}
+}
+
+// WithResponseModifier adds a hook changing the response created from the invoke output before it is
+// returned, e.g. to rewrite headers or the status. Modifiers run in order, an error fails the request.
+func WithResponseModifier(modify func(ctx context.Context, resp *http.Response, out *apigateway.TestInvokeMethodOutput) error) Option {
+	return func(t *Transport) {
+		t.responseModifiers = append(t.responseModifiers, modify)
+	}
+}
@@ -0,0 +1,282 @@
+package transport
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+)
+
+// LambdaClient invokes a Lambda function synchronously, returning its result payload.
+// A function error (the FunctionError of the Invoke output) must be returned as an error.
+//
+// With a *lambda.Client:
+//
+//	transport.LambdaClientFunc(func(ctx context.Context, function string, payload []byte) ([]byte, error) {
+//		out, err := lambdaCli.Invoke(ctx, &lambda.InvokeInput{FunctionName: &function, Payload: payload})
+//		if err != nil {
+//			return nil, err
+//		}
+//
+//		if out.FunctionError != nil {
+//			return nil, fmt.Errorf("function error: %s: %s", *out.FunctionError, out.Payload)
+//		}
+//
+//		return out.Payload, nil
+//	})
+type LambdaClient interface {
+	InvokeFunction(ctx context.Context, function string, payload []byte) ([]byte, error)
+}
+
+// LambdaClientFunc is a function [LambdaClient].
+type LambdaClientFunc func(ctx context.Context, function string, payload []byte) ([]byte, error)
+
+func (f LambdaClientFunc) InvokeFunction(ctx context.Context, function string, payload []byte) ([]byte, error) {
+	return f(ctx, function, payload)
+}
+
+type lambdaRoute struct {
+	patterns routePatterns
+	client   LambdaClient
+	function string
+}
+
+// WithLambdaProxy invokes function directly instead of calling TestInvokeMethod for the routes
+// matching any of the patterns, all the routes when none is given. It is meant for the routes whose
+// integration is a Lambda proxy (AWS_PROXY) one, which TestInvokeMethod limits in payload size and
+// duration: the function gets the API Gateway proxy event built from the invoke input, and its proxy
+// response is converted back. Authorizers, request validation and mapping templates are skipped.
+//
+// It can be used several times, the first matching function is invoked. Patterns have the
+// method#path form of the route templates (e.g. POST#/api/v1/users/{value}). The request bodies of
+// these routes are not limited by [WithMaxBodySize].
+func WithLambdaProxy(client LambdaClient, function string, patterns ...string) Option {
+	ps := make(routePatterns, 0, len(patterns))
+	for _, pattern := range patterns {
+		ps = append(ps, newRoutePattern(pattern))
+	}
+
+	return func(t *Transport) {
+		t.lambdaRoutes = append(t.lambdaRoutes, lambdaRoute{patterns: ps, client: client, function: function})
+	}
+}
+
+// lambdaRoute returns the [WithLambdaProxy] function of the resource, matched with the request method.
+func (t *Transport) lambdaRoute(method string, res resource) (lambdaRoute, bool) {
+	for _, lr := range t.lambdaRoutes {
+		if len(lr.patterns) == 0 || lr.patterns.match(method, res.path) {
+			return lr, true
+		}
+	}
+
+	return lambdaRoute{}, false
+}
+
+// lambdaProxyRequest is the API Gateway REST API proxy integration event (payload format 1.0).
+type lambdaProxyRequest struct {
+	Resource                        string                    `json:"resource"`
+	Path                            string                    `json:"path"`
+	HTTPMethod                      string                    `json:"httpMethod"`
+	Headers                         map[string]string         `json:"headers"`
+	MultiValueHeaders               map[string][]string       `json:"multiValueHeaders"`
+	QueryStringParameters           map[string]string         `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string       `json:"multiValueQueryStringParameters"`
+	PathParameters                  map[string]string         `json:"pathParameters"`
+	StageVariables                  map[string]string         `json:"stageVariables"`
+	RequestContext                  lambdaProxyRequestContext `json:"requestContext"`
+	Body                            *string                   `json:"body"`
+	IsBase64Encoded                 bool                      `json:"isBase64Encoded"`
+}
+
+type lambdaProxyRequestContext struct {
+	ResourceID       string `json:"resourceId"`
+	ResourcePath     string `json:"resourcePath"`
+	HTTPMethod       string `json:"httpMethod"`
+	Path             string `json:"path"`
+	Stage            string `json:"stage"`
+	APIID            string `json:"apiId"`
+	Protocol         string `json:"protocol"`
+	RequestTimeEpoch int64  `json:"requestTimeEpoch"`
+}
+
+// lambdaProxyResponse is the response of a Lambda proxy integration.
+type lambdaProxyResponse struct {
+	StatusCode        int                 `json:"statusCode"`
+	Headers           map[string]string   `json:"headers"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// invokeLambda invokes the function of lr with the proxy event of the invoke input, returning the
+// proxy response as an invoke output.
+func (t *Transport) invokeLambda(
+	ctx context.Context,
+	r *http.Request,
+	input *apigateway.TestInvokeMethodInput,
+	res resource,
+	lr lambdaRoute,
+) (*apigateway.TestInvokeMethodOutput, error) {
+	event, err := t.lambdaProxyRequest(ctx, r, input, res)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("lambda proxy event encode error: %w", err)
+	}
+
+	start := t.clock.Now()
+
+	result, err := lr.client.InvokeFunction(ctx, lr.function, payload)
+	if err != nil {
+		return nil, fmt.Errorf("lambda invoke error: %w", err)
+	}
+
+	latency := t.clock.Now().Sub(start)
+
+	var proxyResp lambdaProxyResponse
+	if err = json.Unmarshal(result, &proxyResp); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedProxyResponse, err)
+	}
+
+	if proxyResp.StatusCode == 0 {
+		return nil, fmt.Errorf("%w: no status code", ErrMalformedProxyResponse)
+	}
+
+	body := proxyResp.Body
+
+	if proxyResp.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: body: %w", ErrMalformedProxyResponse, err)
+		}
+
+		body = string(decoded)
+	}
+
+	return &apigateway.TestInvokeMethodOutput{
+		Status:            int32(proxyResp.StatusCode),
+		Body:              aws.String(body),
+		Headers:           proxyResp.Headers,
+		MultiValueHeaders: mergeProxyHeaders(proxyResp.Headers, proxyResp.MultiValueHeaders),
+		Latency:           latency.Milliseconds(),
+	}, nil
+}
+
+func (t *Transport) lambdaProxyRequest(
+	ctx context.Context,
+	r *http.Request,
+	input *apigateway.TestInvokeMethodInput,
+	res resource,
+) (lambdaProxyRequest, error) {
+	path, rawQuery, _ := strings.Cut(aws.ToString(input.PathWithQueryString), "?")
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return lambdaProxyRequest{}, fmt.Errorf("lambda proxy event query error: %w", err)
+	}
+
+	ic, _ := InvokeContextFromContext(ctx)
+	stage := cmp.Or(ic.Stage, t.stage)
+	params, _ := PathParametersFromContext(ctx)
+
+	stagePath := path
+	if stage != "" {
+		stagePath = "/" + stage + path
+	}
+
+	event := lambdaProxyRequest{
+		Resource:                        res.path,
+		Path:                            path,
+		HTTPMethod:                      aws.ToString(input.HttpMethod),
+		Headers:                         lastValues(input.MultiValueHeaders),
+		MultiValueHeaders:               input.MultiValueHeaders,
+		QueryStringParameters:           lastValues(query),
+		MultiValueQueryStringParameters: nilIfEmpty(query),
+		PathParameters:                  nilIfEmpty(params),
+		StageVariables:                  nilIfEmpty(input.StageVariables),
+		RequestContext: lambdaProxyRequestContext{
+			ResourceID:       res.id,
+			ResourcePath:     res.path,
+			HTTPMethod:       aws.ToString(input.HttpMethod),
+			Path:             stagePath,
+			Stage:            stage,
+			APIID:            aws.ToString(input.RestApiId),
+			Protocol:         r.Proto,
+			RequestTimeEpoch: t.clock.Now().UnixMilli(),
+		},
+		Body: input.Body,
+	}
+
+	if r.GetBody != nil {
+		body, err := r.GetBody()
+		if err != nil {
+			return lambdaProxyRequest{}, fmt.Errorf("lambda proxy event body error: %w", err)
+		}
+
+		raw, err := io.ReadAll(body)
+		if err != nil {
+			return lambdaProxyRequest{}, fmt.Errorf("lambda proxy event body error: %w", err)
+		}
+
+		event.IsBase64Encoded = isBinaryBody(r.Header.Get("Content-Type"), raw, t.binaryMediaTypes)
+	}
+
+	return event, nil
+}
+
+// mergeProxyHeaders merges the single value headers of a proxy response into the multi-value ones,
+// as API Gateway does: the multi-value ones take precedence.
+func mergeProxyHeaders(headers map[string]string, multiValueHeaders map[string][]string) map[string][]string {
+	if len(headers) == 0 {
+		return multiValueHeaders
+	}
+
+	merged := make(map[string][]string, len(headers)+len(multiValueHeaders))
+
+	for name, value := range headers {
+		merged[name] = []string{value}
+	}
+
+	for name, values := range multiValueHeaders {
+		merged[name] = values
+	}
+
+	return merged
+}
+
+// lastValues returns the last value of every key, as the single value proxy event fields hold.
+func lastValues(values map[string][]string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	last := make(map[string]string, len(values))
+
+	for key, vs := range values {
+		if len(vs) > 0 {
+			last[key] = vs[len(vs)-1]
+		}
+	}
+
+	return last
+}
+
+// nilIfEmpty returns nil for empty maps, which the proxy events hold as null.
+func nilIfEmpty[M ~map[K]V, K comparable, V any](m M) M {
+	if len(m) == 0 {
+		return nil
+	}
+
+	return m
+}
@@ -0,0 +1,71 @@
+package transport
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// LocalStackEndpoint is the default LocalStack endpoint, e.g. to set as the config BaseEndpoint.
+const LocalStackEndpoint = "http://localhost.localstack.cloud:4566"
+
+// localStackInvokeDomain is the domain of the LocalStack invoke URLs: {api-id}.execute-api.localhost.localstack.cloud.
+const localStackInvokeDomain = ".execute-api.localhost.localstack.cloud"
+
+// WithLocalStack routes the requests the way emulators (LocalStack, moto) serve the API:
+//   - the {api-id}.execute-api.localhost.localstack.cloud[:4566] hosts are invoke URL hosts, their
+//     first path segment is the stage
+//   - the /restapis/{api-id}/{stage}/_user_request_ path prefix of the emulator endpoint is
+//     removed, on any host
+//
+// It is enabled by [NewTransportFromConfig] when the config BaseEndpoint is a local endpoint.
+func WithLocalStack() Option {
+	return func(t *Transport) {
+		t.localStack = true
+	}
+}
+
+func (t *Transport) isLocalStackHost(host string) bool {
+	return t.localStack && strings.HasPrefix(host, t.apiID+localStackInvokeDomain)
+}
+
+// cutUserRequestPath removes the /restapis/{api-id}/{stage}/_user_request_ prefix of the emulators
+// path, returning the stage and the API path.
+func (t *Transport) cutUserRequestPath(path string) (string, string, bool) {
+	if !t.localStack {
+		return "", path, false
+	}
+
+	rest, found := strings.CutPrefix(path, "/restapis/"+t.apiID+"/")
+	if !found {
+		return "", path, false
+	}
+
+	stage, rest, found := strings.Cut(rest, "/_user_request_")
+	if !found || stage == "" || strings.Contains(stage, "/") || (rest != "" && rest[0] != '/') {
+		return "", path, false
+	}
+
+	if rest == "" {
+		rest = "/"
+	}
+
+	return stage, rest, true
+}
+
+// isLocalEndpoint reports whether endpoint is an emulator endpoint: a loopback or localstack.cloud host.
+func isLocalEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || host == "localstack" || strings.HasSuffix(host, ".localstack.cloud") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
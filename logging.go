@@ -1,9 +1,16 @@
 package transport
 
 import (
+	"cmp"
+	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/apigateway"
 )
 
@@ -11,31 +18,167 @@ func nopLogger() *slog.Logger {
 	return slog.New(slog.NewJSONHandler(io.Discard, nil))
 }
 
-func invokeInputLogGroup(i *apigateway.TestInvokeMethodInput) slog.Attr {
-	return slog.Group("api_gw_input",
-		slog.String("resource_id", *i.ResourceId),
-		slog.String("http_method", *i.HttpMethod),
-		slog.String("path_with_query_string", *i.PathWithQueryString),
-		slog.String("body", bodyLog(i.Body)),
-		slog.Any("headers", i.Headers),
-		slog.Any("multi_headers_value", i.MultiValueHeaders),
-	)
+// mappingLog renders a mapping for the logs once, as large APIs are expensive to render.
+type mappingLog struct {
+	mapping resourceMapping
+	once    sync.Once
+	value   slog.Value
+}
+
+func (l *mappingLog) LogValue() slog.Value {
+	l.once.Do(func() {
+		l.value = l.mapping.LogValue()
+	})
+
+	return l.value
 }
 
-func invokeOutputLogGroup(o *apigateway.TestInvokeMethodOutput) slog.Attr {
-	return slog.Group("api_gw_output",
-		slog.Int("status", int(o.Status)),
-		slog.String("body", bodyLog(o.Body)),
-		slog.Any("headers", o.Headers),
-		slog.Any("multi_headers_value", o.MultiValueHeaders),
-		slog.Int64("latency", o.Latency),
+// PayloadLog sets which parts of the invoke payloads the debug logs include, see [WithPayloadLog].
+type PayloadLog int
+
+const (
+	// PayloadLogFull logs the headers and the whole bodies, the default.
+	PayloadLogFull PayloadLog = iota
+	// PayloadLogTruncated logs the headers and the bodies truncated to the [WithLogBodyLimit] limit,
+	// 1 KiB by default.
+	PayloadLogTruncated
+	// PayloadLogHeaders logs the headers without the bodies.
+	PayloadLogHeaders
+	// PayloadLogNone logs neither the headers nor the bodies, only the resource, the path and the status.
+	PayloadLogNone
+)
+
+const defaultLogBodyLimit = 1 << 10
+
+func (t *Transport) invokeInputLogGroup(i *apigateway.TestInvokeMethodInput) slog.Attr {
+	attrs := []any{
+		slog.String("resource_id", aws.ToString(i.ResourceId)),
+		slog.String("http_method", aws.ToString(i.HttpMethod)),
+		slog.String("path_with_query_string", aws.ToString(i.PathWithQueryString)),
+	}
+
+	return slog.Group("api_gw_input", t.payloadLogAttrs(attrs, i.Body, i.Headers, i.MultiValueHeaders)...)
+}
+
+func (t *Transport) invokeOutputLogGroup(o *apigateway.TestInvokeMethodOutput) slog.Attr {
+	attrs := t.payloadLogAttrs([]any{slog.Int("status", int(o.Status))}, o.Body, o.Headers, o.MultiValueHeaders)
+
+	return slog.Group("api_gw_output", append(attrs, slog.Int64("latency", o.Latency))...)
+}
+
+// payloadLogAttrs appends to attrs the payload parts logged with the [PayloadLog] of the transport.
+func (t *Transport) payloadLogAttrs(
+	attrs []any,
+	body *string,
+	headers map[string]string,
+	multiValueHeaders map[string][]string,
+) []any {
+	switch t.payloadLog {
+	case PayloadLogFull:
+		attrs = append(attrs, slog.String("body", bodyLog(body, 0)))
+	case PayloadLogTruncated:
+		attrs = append(attrs, slog.String("body", bodyLog(body, cmp.Or(t.logBodyLimit, defaultLogBodyLimit))))
+	case PayloadLogNone:
+		return attrs
+	}
+
+	return append(attrs,
+		slog.Any("headers", headers),
+		slog.Any("multi_headers_value", multiValueHeaders),
 	)
 }
 
-func bodyLog(content *string) string {
-	if content != nil {
-		return *content
+// bodyLog returns the logged content, truncated to limit bytes when limit is positive.
+func bodyLog(content *string, limit int) string {
+	if content == nil {
+		return "(no body)"
+	}
+
+	body := *content
+	if limit <= 0 || len(body) <= limit {
+		return body
+	}
+
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut-- // do not split a multibyte character
+	}
+
+	return fmt.Sprintf("%s... (%d bytes)", body[:cut], len(body))
+}
+
+// WithPayloadLog sets which parts of the invoke input and output the debug logs include.
+// The payloads are only rendered when the debug level is enabled.
+func WithPayloadLog(mode PayloadLog) Option {
+	return func(t *Transport) {
+		t.payloadLog = mode
 	}
+}
+
+// WithLogBodyLimit caps the request and response bodies in the debug logs to n bytes,
+// truncated bodies end with an ellipsis and the full size, as [PayloadLogTruncated] does.
+// Bodies are logged whole by default.
+func WithLogBodyLimit(n int) Option {
+	return func(t *Transport) {
+		t.logBodyLimit = n
+
+		if t.payloadLog == PayloadLogFull && n > 0 {
+			t.payloadLog = PayloadLogTruncated
+		}
+	}
+}
+
+// WithoutLogBodies omits the request and response bodies from the debug logs,
+// the status and the headers are still logged, as [PayloadLogHeaders] does.
+func WithoutLogBodies() Option {
+	return WithPayloadLog(PayloadLogHeaders)
+}
+
+// WithMappingsDebugLog logs the mapped resources of the API on every request, at debug level.
+func WithMappingsDebugLog() Option {
+	return func(t *Transport) {
+		t.logMappings = true
+	}
+}
+
+// WithAccessLog logs one info record per request with the request logger, as access logs do,
+// whatever the debug logs: the method, the path, the route template and resource ID, the status,
+// the durations and the body sizes. Failed requests are logged with the error instead of the status.
+// Quiet routes (see [WithQuietRoutes]) are not logged.
+func WithAccessLog() Option {
+	return func(t *Transport) {
+		t.accessLog = true
+	}
+}
+
+// logAccess writes the [WithAccessLog] record of a request.
+func (t *Transport) logAccess(r *http.Request, resp *http.Response, err error, duration time.Duration) {
+	ctx := r.Context()
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.Duration("duration", duration), slog.Any("error", err))
+		t.requestLog(ctx).LogAttrs(ctx, slog.LevelInfo, "request failed", attrs...)
+
+		return
+	}
+
+	if route, found := RouteFromContext(resp.Request.Context()); found {
+		attrs = append(attrs, slog.String("route", route.Template), slog.String("resource_id", route.ResourceID))
+	}
+
+	attrs = append(attrs, slog.Int("status", resp.StatusCode), slog.Duration("duration", duration))
+
+	if latency, found := InvokeLatency(resp); found {
+		attrs = append(attrs, slog.Duration("invoke_latency", latency))
+	}
+
+	attrs = append(attrs,
+		slog.Int64("request_size", max(resp.Request.ContentLength, 0)),
+		slog.Int64("response_size", max(resp.ContentLength, 0)))
 
-	return "(no body)"
+	t.requestLog(ctx).LogAttrs(ctx, slog.LevelInfo, "request", attrs...)
 }
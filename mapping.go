@@ -1,77 +1,197 @@
 package transport
 
 import (
-	"context"
 	"fmt"
 	"log/slog"
 	"regexp"
+	"slices"
+	"strings"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/apigateway"
-	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	"github.com/rcarrion2/aws-apigw-invoke-transport/internal/routetemplate"
 )
 
-func mapEndpointResources(cli ApiGwClient, apiID string) (resourceMapping, error) {
-	ctx := context.Background()
+// anyMethod is the API Gateway catch-all method.
+const anyMethod = "ANY"
 
-	resources, err := cli.GetResources(ctx, &apigateway.GetResourcesInput{
-		RestApiId: aws.String(apiID),
-	})
+type resource struct {
+	// id is the aws api gateway resource id.
+	id string
+	// method is the method declared in the resource, it could be ANY.
+	method string
+	path   string
+	regex  *regexp.Regexp
+	// prefix is the literal prefix of the regex, checked before the regex.
+	prefix string
+
+	// requiredParams are the method request parameters marked as required (e.g. querystring.name).
+	requiredParams []string
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("get resources error: %w", err)
+func (r resource) route() Route {
+	return Route{
+		Method:             r.method,
+		Template:           r.path,
+		ResourceID:         r.id,
+		RequiredParameters: r.requiredParams,
 	}
+}
 
-	mapping := resourceMapping{}
+type resourceMapping map[string]resource
 
-	for _, res := range resources.Items {
-		for method := range res.ResourceMethods {
-			if err = mapping.add(res, method); err != nil {
-				return nil, err
-			}
-		}
+// routes returns the routes of the mapping, unsorted.
+func (mappings resourceMapping) routes() []Route {
+	routes := make([]Route, 0, len(mappings))
+	for _, r := range mappings {
+		routes = append(routes, r.route())
 	}
 
-	return mapping, nil
+	return routes
 }
 
-type resource struct {
-	// id is the aws api gateway resource id.
-	id    string
-	regex *regexp.Regexp
+// ResourceNotFoundError is returned when no resource matches the request, it matches [ErrResourceNotFound].
+type ResourceNotFoundError struct {
+	Method string
+	// Path is the matched path, without the stage or the custom domain base path.
+	Path  string
+	APIID string
+
+	// Candidates are the routes (e.g. "POST /api/v1/users") whose template matches the path
+	// with another method, or whose template differs from the path by a trailing slash.
+	Candidates []string
 }
 
-type resourceMapping map[string]resource
+func (e *ResourceNotFoundError) Error() string {
+	return fmt.Sprintf("%s: %s %s", ErrResourceNotFound, e.Method, e.Path)
+}
+
+func (e *ResourceNotFoundError) Unwrap() error {
+	return ErrResourceNotFound
+}
 
-func (mappings resourceMapping) matchResourceID(method, path string) (string, bool) {
-	key := endpointKey(method, path)
+// match finds the resource for the request method and path.
+// As API Gateway, it picks the most specific resource (see [resource.precedes]) first and then the method:
+// a method declared explicitly has precedence over the ANY method of the same template only.
+func (mappings resourceMapping) match(method, path string) (resource, bool) {
+	r, found := mappings.matchKey(endpointKey(method, path))
 
-	if r, found := mappings[key]; found {
-		return r.id, true
+	anyRes, anyFound := mappings.matchKey(endpointKey(anyMethod, path))
+	if anyFound && (!found || anyRes.path != r.path && anyRes.precedes(r)) {
+		return anyRes, true
 	}
 
+	return r, found
+}
+
+// pathParameters returns the values of the template variables in path (e.g. value for
+// /api/v1/users/{value}), nil when the resource has no variables or does not match path.
+func (r resource) pathParameters(path string) map[string]string {
+	if r.regex == nil || !strings.Contains(r.path, "{") {
+		return nil
+	}
+
+	values := r.regex.FindStringSubmatch(endpointKey(r.method, path))
+	if values == nil {
+		return nil
+	}
+
+	params := make(map[string]string, len(values)-1)
+
+	for _, segment := range strings.Split(r.path, "/") {
+		if routetemplate.SegmentKind(segment) != routetemplate.Literal && len(params) < len(values)-1 {
+			params[routetemplate.VarName(segment)] = values[len(params)+1]
+		}
+	}
+
+	return params
+}
+
+// byID returns the method resource with id, for resources not mapped (e.g. with only a subset
+// of the sources) the resource has the request method and path.
+func (mappings resourceMapping) byID(id, method, path string) resource {
+	var anyRes resource
+
 	for _, r := range mappings {
-		if r.regex.MatchString(key) {
-			return r.id, true
+		switch {
+		case r.id != id:
+		case r.method == method:
+			return r
+		case r.method == anyMethod:
+			anyRes = r
 		}
 	}
 
-	return "", false
+	if anyRes.id != "" {
+		return anyRes
+	}
+
+	return resource{id: id, method: method, path: path}
 }
 
-func (mappings resourceMapping) add(r types.Resource, method string) error {
+// candidates returns the near misses of a request without resource, sorted.
+func (mappings resourceMapping) candidates(method, path string) []string {
+	alt := strings.TrimSuffix(path, "/")
+	if alt == path {
+		alt = path + "/"
+	}
+
+	var candidates []string
+
+	for _, r := range mappings {
+		nearMiss := r.regex.MatchString(endpointKey(r.method, path)) ||
+			(r.method == method || r.method == anyMethod) && r.regex.MatchString(endpointKey(r.method, alt))
+
+		if nearMiss {
+			candidates = append(candidates, r.method+" "+r.path)
+		}
+	}
+
+	slices.Sort(candidates)
+
+	return candidates
+}
+
+func (mappings resourceMapping) matchKey(key string) (resource, bool) {
+	if r, found := mappings[key]; found {
+		return r, true
+	}
+
 	var (
-		resourceID = *r.Id
-		path       = *r.Path
-		key        = endpointKey(method, path)
+		best  resource
+		found bool
 	)
 
+	for _, r := range mappings {
+		if strings.HasPrefix(key, r.prefix) && r.regex.MatchString(key) && (!found || r.precedes(best)) {
+			best, found = r, true
+		}
+	}
+
+	return best, found
+}
+
+// precedes reports whether r has precedence over other when both match a request, see [routetemplate.Precedes].
+func (r resource) precedes(other resource) bool {
+	return routetemplate.Precedes(r.path, other.path)
+}
+
+func (mappings resourceMapping) add(route Route) error {
+	key := endpointKey(route.Method, route.Template)
+
 	regex, err := resourceRegex(key)
 	if err != nil {
 		return err
 	}
 
-	mappings[key] = resource{id: resourceID, regex: regex}
+	prefix, _ := regex.LiteralPrefix()
+
+	mappings[key] = resource{
+		id:             route.ResourceID,
+		method:         route.Method,
+		path:           route.Template,
+		regex:          regex,
+		prefix:         prefix,
+		requiredParams: route.RequiredParameters,
+	}
 
 	return nil
 }
@@ -79,7 +199,15 @@ func (mappings resourceMapping) add(r types.Resource, method string) error {
 func (mappings resourceMapping) LogValue() slog.Value {
 	attrs := make([]slog.Attr, 0, len(mappings))
 
-	for k, r := range mappings {
+	keys := make([]string, 0, len(mappings))
+	for k := range mappings {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		r := mappings[k]
 		attrs = append(attrs, slog.Group(k,
 			slog.String("resource_id", r.id),
 			slog.String("pattern", r.regex.String())))
@@ -89,12 +217,7 @@ func (mappings resourceMapping) LogValue() slog.Value {
 }
 
 func resourceRegex(key string) (*regexp.Regexp, error) {
-	pattern := regexp.QuoteMeta(key)
-	pattern = regexp.MustCompile(`\\{[^/]+}`).ReplaceAllString(pattern, `([^/]+)`)
-	pattern = "^" + pattern + "$"
-
-	regex, err := regexp.Compile(pattern)
-
+	regex, err := routetemplate.Regex(key)
 	if err != nil {
 		return nil, fmt.Errorf("could not compile resource regex: %w", err)
 	}
@@ -103,5 +226,5 @@ func resourceRegex(key string) (*regexp.Regexp, error) {
 }
 
 func endpointKey(method, path string) string {
-	return fmt.Sprintf("%s#%s", method, path) // e.g. POST#/path/to/resource
+	return method + "#" + path // e.g. POST#/path/to/resource
 }
@@ -0,0 +1,30 @@
+package transport
+
+import "time"
+
+// Metrics records the transport behavior, see [WithMetrics].
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// Invoked is called after every successful invoke, with the invoked route and status.
+	Invoked(route Route, status int, duration time.Duration)
+	// Failed is called for every request failing, errType is the error type of the
+	// run [Summary] (e.g. resource_not_found, other).
+	Failed(errType string)
+	// MatchMissed is called for every request no resource matches.
+	MatchMissed(method string)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) Invoked(Route, int, time.Duration) {}
+func (nopMetrics) Failed(string)                     {}
+func (nopMetrics) MatchMissed(string)                {}
+
+// WithMetrics records the transport behavior in m. Quiet routes are not recorded.
+// See the prommetrics package for a Prometheus implementation. When m implements [io.Closer],
+// it is closed by [Transport.Close].
+func WithMetrics(m Metrics) Option {
+	return func(t *Transport) {
+		t.metrics = m
+	}
+}
@@ -0,0 +1,29 @@
+package transport
+
+import "strings"
+
+// partitions are the invoke URL DNS suffixes of the AWS partitions, by region prefix.
+// The first suffix is the default one.
+var partitions = []struct {
+	regionPrefix string
+	dnsSuffixes  []string
+}{
+	{regionPrefix: "cn-", dnsSuffixes: []string{"amazonaws.com.cn"}},
+	{regionPrefix: "us-gov-", dnsSuffixes: []string{"amazonaws.com", "amazonaws-us-gov.com"}},
+	{regionPrefix: "us-isob-", dnsSuffixes: []string{"sc2s.sgov.gov"}},
+	{regionPrefix: "us-isof-", dnsSuffixes: []string{"csp.hci.ic.gov"}},
+	{regionPrefix: "us-iso-", dnsSuffixes: []string{"c2s.ic.gov"}},
+	{regionPrefix: "eu-isoe-", dnsSuffixes: []string{"cloud.adc-e.uk"}},
+}
+
+// partitionDNSSuffixes returns the invoke URL DNS suffixes of the region partition,
+// regions of unknown partitions are in the aws one.
+func partitionDNSSuffixes(region string) []string {
+	for _, p := range partitions {
+		if strings.HasPrefix(region, p.regionPrefix) {
+			return p.dnsSuffixes
+		}
+	}
+
+	return []string{"amazonaws.com"}
+}
@@ -0,0 +1,47 @@
+package transport
+
+import "regexp"
+
+// routePattern matches requests against a method#path pattern, the same form [Transport.Mappings]
+// keys use, path variables included (e.g. GET#/api/v1/users/{value}). ANY patterns match every method.
+type routePattern struct {
+	pattern string
+	regex   *regexp.Regexp
+}
+
+func newRoutePattern(pattern string) routePattern {
+	regex, err := resourceRegex(pattern)
+	if err != nil {
+		panic(err) // the pattern is quoted before compiling, so this should never happen.
+	}
+
+	return routePattern{pattern: pattern, regex: regex}
+}
+
+func (p routePattern) match(method, path string) bool {
+	return p.regex.MatchString(endpointKey(method, path)) || p.regex.MatchString(endpointKey(anyMethod, path))
+}
+
+type routePatterns []routePattern
+
+func (ps routePatterns) match(method, path string) bool {
+	for _, p := range ps {
+		if p.match(method, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithQuietRoutes excludes the requests matching any of the patterns (e.g. health checks, polling)
+// from request logs and run summaries, while still serving them.
+//
+// Patterns have the method#path form (e.g. GET#/health).
+func WithQuietRoutes(patterns ...string) Option {
+	return func(t *Transport) {
+		for _, p := range patterns {
+			t.quietRoutes = append(t.quietRoutes, newRoutePattern(p))
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+)
+
+// preflightResourceID is a resource ID no API has, TestInvokeMethod fails with 404 Not Found for it
+// when the caller is allowed to invoke, and with 403 Forbidden otherwise.
+const preflightResourceID = "preflight-check"
+
+// ValidationReport is the result of [Transport.Validate].
+type ValidationReport struct {
+	APIID string
+	// APIFound reports whether the API exists, it is false when the resources can not be read.
+	APIFound bool
+	// CanGetResources reports whether the caller is allowed to read the API resources (apigateway:GET).
+	CanGetResources bool
+	// CanTestInvoke reports whether the caller is allowed to call TestInvokeMethod (apigateway:POST).
+	CanTestInvoke bool
+	// Routes is the number of routable methods mapped.
+	Routes int
+
+	// Problems describes the failed checks, empty when the transport is ready to invoke.
+	Problems []string
+}
+
+// Validate checks the transport is able to invoke the API, e.g. to fail fast at startup: the API
+// exists, the caller is allowed to read its resources and to call TestInvokeMethod, and at least
+// one routable method is mapped. The mappings are initialized as the first request would.
+//
+// Failed checks are listed in the report and returned as an error wrapping [ErrValidationFailed],
+// other errors (e.g. network ones) are returned as they are.
+func (t *Transport) Validate(ctx context.Context) (ValidationReport, error) {
+	report := ValidationReport{APIID: t.apiID}
+
+	switch err := t.initMappings(ctx); statusCodeOf(err) {
+	case 0:
+		if err != nil {
+			return report, err
+		}
+
+		report.APIFound, report.CanGetResources = true, true
+		report.Routes = len(t.currentMapping())
+	case http.StatusNotFound:
+		report.CanGetResources = true
+		report.Problems = append(report.Problems, "rest api not found")
+	case http.StatusForbidden:
+		report.Problems = append(report.Problems, "not allowed to get the resources (apigateway:GET)")
+	default:
+		return report, err
+	}
+
+	_, err := t.client.TestInvokeMethod(ctx, &apigateway.TestInvokeMethodInput{
+		RestApiId:  aws.String(t.apiID),
+		ResourceId: aws.String(preflightResourceID),
+		HttpMethod: aws.String(http.MethodGet),
+	})
+
+	switch statusCodeOf(err) {
+	case 0:
+		if err != nil {
+			return report, fmt.Errorf("test invoke error: %w", err)
+		}
+
+		report.CanTestInvoke = true
+	case http.StatusNotFound:
+		report.CanTestInvoke = true
+	case http.StatusForbidden:
+		report.Problems = append(report.Problems, "not allowed to test invoke (apigateway:POST)")
+	default:
+		return report, fmt.Errorf("test invoke error: %w", err)
+	}
+
+	if report.APIFound && report.Routes == 0 {
+		report.Problems = append(report.Problems, "no routable methods")
+	}
+
+	if len(report.Problems) > 0 {
+		return report, fmt.Errorf("%w: %s", ErrValidationFailed, strings.Join(report.Problems, ", "))
+	}
+
+	return report, nil
+}
+
+// statusCodeOf returns the status AWS responded err with, zero for other errors.
+func statusCodeOf(err error) int {
+	var respErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &respErr) {
+		return respErr.HTTPStatusCode()
+	}
+
+	return 0
+}
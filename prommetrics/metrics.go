@@ -0,0 +1,81 @@
+// Package prommetrics records the transport metrics as Prometheus metrics.
+package prommetrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	transport "github.com/rcarrion2/aws-apigw-invoke-transport"
+)
+
+// Collector is a [transport.Metrics] exposing the recorded metrics as a [prometheus.Collector]:
+//
+//   - apigw_transport_invocations_total{method,route,status}
+//   - apigw_transport_invoke_duration_seconds{method,route}
+//   - apigw_transport_errors_total{type}
+//   - apigw_transport_match_misses_total{method}
+type Collector struct {
+	invocations *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+	errors      *prometheus.CounterVec
+	misses      *prometheus.CounterVec
+}
+
+var _ transport.Metrics = (*Collector)(nil)
+
+// NewCollector creates a Collector, it must be registered to expose the metrics
+// (e.g. prometheus.MustRegister(c)).
+func NewCollector() *Collector {
+	return &Collector{
+		invocations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "apigw_transport",
+			Name:      "invocations_total",
+			Help:      "Invocations by route and status.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "apigw_transport",
+			Name:      "invoke_duration_seconds",
+			Help:      "Invoke duration by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "apigw_transport",
+			Name:      "errors_total",
+			Help:      "Failed requests by error type.",
+		}, []string{"type"}),
+		misses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "apigw_transport",
+			Name:      "match_misses_total",
+			Help:      "Requests no resource matches, by method.",
+		}, []string{"method"}),
+	}
+}
+
+func (c *Collector) Invoked(route transport.Route, status int, duration time.Duration) {
+	c.invocations.WithLabelValues(route.Method, route.Template, strconv.Itoa(status)).Inc()
+	c.duration.WithLabelValues(route.Method, route.Template).Observe(duration.Seconds())
+}
+
+func (c *Collector) Failed(errType string) {
+	c.errors.WithLabelValues(errType).Inc()
+}
+
+func (c *Collector) MatchMissed(method string) {
+	c.misses.WithLabelValues(method).Inc()
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.invocations.Describe(ch)
+	c.duration.Describe(ch)
+	c.errors.Describe(ch)
+	c.misses.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.invocations.Collect(ch)
+	c.duration.Collect(ch)
+	c.errors.Collect(ch)
+	c.misses.Collect(ch)
+}
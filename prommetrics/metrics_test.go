@@ -0,0 +1,40 @@
+package prommetrics_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	transport "github.com/rcarrion2/aws-apigw-invoke-transport"
+	"github.com/rcarrion2/aws-apigw-invoke-transport/prommetrics"
+)
+
+func TestCollector(t *testing.T) {
+	// GIVEN
+	route := transport.Route{Method: http.MethodGet, Template: "/api/v1/users/{value}", ResourceID: "2cb3ff"}
+	c := prommetrics.NewCollector()
+
+	// WHEN
+	c.Invoked(route, http.StatusOK, 20*time.Millisecond)
+	c.Invoked(route, http.StatusOK, 30*time.Millisecond)
+	c.Failed("resource_not_found")
+	c.MatchMissed(http.MethodPost)
+
+	// THEN
+	assert.Equal(t, 4, testutil.CollectAndCount(c))
+	assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP apigw_transport_invocations_total Invocations by route and status.
+# TYPE apigw_transport_invocations_total counter
+apigw_transport_invocations_total{method="GET",route="/api/v1/users/{value}",status="200"} 2
+# HELP apigw_transport_errors_total Failed requests by error type.
+# TYPE apigw_transport_errors_total counter
+apigw_transport_errors_total{type="resource_not_found"} 1
+# HELP apigw_transport_match_misses_total Requests no resource matches, by method.
+# TYPE apigw_transport_match_misses_total counter
+apigw_transport_match_misses_total{method="POST"} 1
+`), "apigw_transport_invocations_total", "apigw_transport_errors_total", "apigw_transport_match_misses_total"))
+}
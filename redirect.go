@@ -0,0 +1,129 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// redirectRequest creates the request to follow a redirect response and returns the transport serving it:
+// t, or the transport of the [Registry] t is registered in which the Location host is registered to.
+//
+// The redirect is followed only when the Location targets the same host, the invoke URL or a registered
+// host, and the transport would serve it as [Transport.RoundTrip] does, otherwise the returned request
+// is nil and the response must be returned as is.
+func (t *Transport) redirectRequest(resp *http.Response) (*http.Request, *Transport, error) {
+	if !isRedirect(resp.StatusCode) {
+		return nil, nil, nil
+	}
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return nil, nil, nil
+	}
+
+	r := resp.Request
+
+	target, err := r.URL.Parse(location)
+	if err != nil {
+		return nil, nil, fmt.Errorf("redirect location error: %w", err)
+	}
+
+	to := t
+
+	if target.Host != r.URL.Host && !t.isInvokeHost(target.Host) {
+		reg := t.registry.Load()
+		if reg == nil {
+			return nil, nil, nil
+		}
+
+		if to, _ = reg.transport(hostname(target.Host)); to == nil {
+			return nil, nil, nil
+		}
+	}
+
+	method := r.Method
+	preserveBody := resp.StatusCode == http.StatusTemporaryRedirect || resp.StatusCode == http.StatusPermanentRedirect
+
+	if !preserveBody && method != http.MethodGet && method != http.MethodHead {
+		method = http.MethodGet
+	}
+
+	var body io.ReadCloser = http.NoBody
+
+	if preserveBody && r.Body != nil && r.Body != http.NoBody {
+		if r.GetBody != nil {
+			if body, err = r.GetBody(); err != nil {
+				return nil, nil, fmt.Errorf("redirect body error: %w", err)
+			}
+		} else {
+			body = r.Body
+		}
+	}
+
+	next, err := http.NewRequestWithContext(r.Context(), method, target.String(), body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("redirect request error: %w", err)
+	}
+
+	next.Header = r.Header.Clone()
+
+	if preserveBody && body != http.NoBody {
+		next.GetBody = r.GetBody
+		next.ContentLength = r.ContentLength
+	}
+
+	if !preserveBody {
+		next.Header.Del("Content-Type")
+		next.Header.Del("Content-Length")
+	}
+
+	serves, err := to.serves(next)
+	if err != nil || !serves {
+		return nil, nil, err
+	}
+
+	return next, to, nil
+}
+
+// serves reports whether [Transport.RoundTrip] would serve r: with a stubbed response, a direct
+// request or a resource r matches.
+func (t *Transport) serves(r *http.Request) (bool, error) {
+	if _, stubbed := t.stubs.match(r.Method, t.requestPath(r)); stubbed || t.sendsDirect(r) {
+		return true, nil
+	}
+
+	if _, err := t.Match(r); err != nil {
+		if errors.Is(err, ErrResourceNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	default:
+		return false
+	}
+}
+
+// WithFollowRedirects resolves 3xx responses internally, re-invoking the Location target
+// through the same transport, up to limit redirects per request.
+//
+// Only locations the transport serves are followed: on the request host, the invoke URL, or a host of
+// the [Registry] the transport is registered in, and routed to a resource as [Transport.RoundTrip] would
+// route them (e.g. with [WithHeadFallback], [WithTrailingSlashEquivalence] or [WithStages]).
+// Any other redirect response is returned to the caller.
+func WithFollowRedirects(limit int) Option {
+	return func(t *Transport) {
+		t.maxRedirects = limit
+	}
+}
@@ -0,0 +1,155 @@
+package transport
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Refresh fetches the resources again and atomically swaps the mapping, so resources deployed
+// after the initialization become routable. In-flight requests keep using the previous mapping,
+// which is also kept when the refresh fails.
+func (t *Transport) Refresh(ctx context.Context) error {
+	t.initLog.DebugContext(ctx, "refreshing endpoint mappings")
+
+	mapping, err := t.buildMapping(ctx)
+	t.stats.refreshed(err)
+
+	if err != nil {
+		return err
+	}
+
+	previous := t.currentMapping()
+
+	t.setMapping(mapping)
+	t.initLog.DebugContext(ctx, "mappings refreshed", slog.Int("resources", len(mapping)))
+
+	if diff := DiffRoutes(previous.routes(), mapping.routes()); !diff.Empty() {
+		t.initLog.InfoContext(ctx, "mappings changed",
+			slog.Any("added", routeNames(diff.Added)),
+			slog.Any("removed", routeNames(diff.Removed)),
+			slog.Any("changed", changeNames(diff.Changed)))
+
+		if t.onRefresh != nil {
+			t.onRefresh(diff)
+		}
+	}
+
+	return nil
+}
+
+// WithOnRefresh calls fn with the routes changed by every refresh (see [Transport.Refresh]) changing
+// the mapping, whatever started it, e.g. to notice a redeployment of the API under a long-running test.
+func WithOnRefresh(fn func(diff RouteDiff)) Option {
+	return func(t *Transport) {
+		t.onRefresh = fn
+	}
+}
+
+// Watch refreshes the mapping every interval until ctx is done, the routes changed are logged and
+// given to the [WithOnRefresh] callback. The mapping is initialized first, failed refreshes are logged
+// and retried on the next interval. It returns the initialization error or the ctx one once done.
+//
+//	go tr.Watch(ctx, time.Minute)
+func (t *Transport) Watch(ctx context.Context, interval time.Duration) error {
+	if err := t.initMappings(ctx); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.clock.After(interval):
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := t.Refresh(ctx); err != nil && ctx.Err() == nil {
+			t.initLog.WarnContext(ctx, "mappings watch refresh failed", slog.Any("error", err))
+		}
+	}
+}
+
+func routeNames(routes []Route) []string {
+	names := make([]string, len(routes))
+	for i, r := range routes {
+		names[i] = r.Method + " " + r.Template
+	}
+
+	return names
+}
+
+func changeNames(changes []RouteChange) []string {
+	names := make([]string, len(changes))
+	for i, c := range changes {
+		names[i] = c.After.Method + " " + c.After.Template
+	}
+
+	return names
+}
+
+// refreshIfExpired starts a background refresh when the mapping is older than the TTL.
+// Only one refresh runs at a time and requests are never blocked by it.
+func (t *Transport) refreshIfExpired() {
+	if t.mappingTTL <= 0 {
+		return
+	}
+
+	t.mu.RLock()
+	expired := t.clock.Now().Sub(t.mappedAt) >= t.mappingTTL
+	t.mu.RUnlock()
+
+	if !expired || !t.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer t.refreshing.Store(false)
+
+		if err := t.Refresh(context.Background()); err != nil {
+			t.initLog.Warn("mappings refresh failed, keeping previous mappings", slog.Any("error", err))
+		}
+	}()
+}
+
+// WithMappingTTL refreshes the resource mapping in the background once it is older than ttl,
+// so resources added after the initialization become routable without restarting.
+// The refresh happens lazily on the first request after the mapping expired.
+func WithMappingTTL(ttl time.Duration) Option {
+	return func(t *Transport) {
+		t.mappingTTL = ttl
+	}
+}
+
+// refreshOnMiss refreshes the mapping after a request missed it, at most once per interval.
+// Concurrent misses wait for the ongoing refresh instead of starting another one.
+func (t *Transport) refreshOnMiss(ctx context.Context) {
+	t.missRefreshMu.Lock()
+	defer t.missRefreshMu.Unlock()
+
+	now := t.clock.Now()
+
+	if !t.missRefreshedAt.IsZero() && now.Sub(t.missRefreshedAt) < t.missRefreshInterval {
+		return
+	}
+
+	t.missRefreshedAt = now
+
+	if err := t.Refresh(ctx); err != nil {
+		t.initLog.WarnContext(ctx, "mappings refresh on miss failed", slog.Any("error", err))
+	}
+}
+
+// WithRefreshOnMiss refreshes the mapping and retries the match once when a request does not
+// match any resource, so routes deployed moments ago are found.
+// Refreshes are rate-limited to one per interval, a zero interval refreshes on every miss.
+// Only the transport API is refreshed, not the APIs selected by [InvokeContext] or base path mappings.
+func WithRefreshOnMiss(interval time.Duration) Option {
+	return func(t *Transport) {
+		t.missRefresh = true
+		t.missRefreshInterval = interval
+	}
+}
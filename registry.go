@@ -0,0 +1,133 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Registry is a [http.RoundTripper] holding many per-API transports, that dispatches
+// each request based on its host (invoke URL or custom domain).
+// So a single [http.Client] can talk to several APIs.
+type Registry struct {
+	mu         sync.RWMutex
+	transports map[string]*Transport
+
+	clientFactory func(region string) ApiGwClient
+	clients       map[string]ApiGwClient
+}
+
+// RegistryOption configures a [Registry].
+type RegistryOption func(*Registry)
+
+func NewRegistry(opts ...RegistryOption) *Registry {
+	reg := &Registry{transports: map[string]*Transport{}, clients: map[string]ApiGwClient{}}
+
+	for _, opt := range opts {
+		opt(reg)
+	}
+
+	return reg
+}
+
+// WithClientFactory sets the function creating the client of a region for [Registry.RegisterAPI],
+// it is called once per region:
+//
+//	transport.WithClientFactory(func(region string) transport.ApiGwClient {
+//		return apigateway.NewFromConfig(cfg, func(o *apigateway.Options) { o.Region = region })
+//	})
+func WithClientFactory(factory func(region string) ApiGwClient) RegistryOption {
+	return func(reg *Registry) {
+		reg.clientFactory = factory
+	}
+}
+
+// RegisterAPI creates and registers the transport of an API living in region, with the regional client
+// of the [WithClientFactory] factory. The hosts are registered as in [Registry.Register].
+func (reg *Registry) RegisterAPI(apiID, region string, hosts []string, opts ...Option) (*Transport, error) {
+	client, err := reg.client(region)
+	if err != nil {
+		return nil, err
+	}
+
+	t := NewTransport(client, apiID, append([]Option{WithRegion(region)}, opts...)...)
+	reg.Register(t, hosts...)
+
+	return t, nil
+}
+
+func (reg *Registry) client(region string) (ApiGwClient, error) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if reg.clientFactory == nil {
+		return nil, fmt.Errorf("%w: register %s API", ErrNoClientFactory, region)
+	}
+
+	client, found := reg.clients[region]
+	if !found {
+		client = reg.clientFactory(region)
+		reg.clients[region] = client
+	}
+
+	return client, nil
+}
+
+// Register adds the transport for its invoke URL hosts and the given hosts (e.g. custom domains).
+// The transport follows the [WithFollowRedirects] redirects to the registered hosts too.
+func (reg *Registry) Register(t *Transport, hosts ...string) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	t.registry.Store(reg)
+
+	for _, host := range t.invokeURLHosts {
+		reg.transports[host] = t
+	}
+
+	for _, host := range hosts {
+		reg.transports[strings.ToLower(host)] = t
+	}
+}
+
+func (reg *Registry) RoundTrip(r *http.Request) (*http.Response, error) {
+	host := requestHostname(r)
+
+	t, found := reg.transport(host)
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrHostNotRegistered, host)
+	}
+
+	return t.RoundTrip(r)
+}
+
+func (reg *Registry) transport(host string) (*Transport, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	t, found := reg.transports[strings.ToLower(host)]
+
+	return t, found
+}
+
+// Close closes all the registered transports.
+func (reg *Registry) Close() error {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	var (
+		errs   []error
+		closed = map[*Transport]bool{}
+	)
+
+	for _, t := range reg.transports {
+		if !closed[t] {
+			closed[t] = true
+			errs = append(errs, t.Close())
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,166 @@
+package transport_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rcarrion2/aws-apigw-invoke-transport"
+	"github.com/rcarrion2/aws-apigw-invoke-transport/transporttest"
+)
+
+func TestRegistry_RoundTrip(t *testing.T) {
+	const (
+		usersAPI  = "us3r5ap1"
+		ordersAPI = "0rd3r5ap1"
+	)
+
+	newClient := func(apiID, resourceID string) *transporttest.Client {
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Maybe()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+				return *i.RestApiId == apiID && *i.ResourceId == resourceID
+			})).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(apiID), Status: http.StatusOK}, nil).
+			Maybe()
+
+		return apiGwCli
+	}
+
+	usersCli := newClient(usersAPI, "2cb3ff")
+	ordersCli := newClient(ordersAPI, "2cb3ff")
+
+	registry := transport.NewRegistry()
+	registry.Register(transport.NewTransport(usersCli, usersAPI), "users.example.com")
+	registry.Register(transport.NewTransport(ordersCli, ordersAPI))
+
+	testCases := map[string]struct {
+		url           string
+		expectedAPIID string
+	}{
+		"custom domain": {
+			url:           "https://users.example.com/api/v1/users/john.doe",
+			expectedAPIID: usersAPI,
+		},
+		"custom domain with port and case": {
+			url:           "https://Users.Example.com:443/api/v1/users/john.doe",
+			expectedAPIID: usersAPI,
+		},
+		"invoke URL": {
+			url:           "https://" + ordersAPI + ".execute-api.us-east-1.amazonaws.com/stage/api/v1/users/john.doe",
+			expectedAPIID: ordersAPI,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			httpReq, err := http.NewRequest(http.MethodGet, tc.url, http.NoBody)
+			require.NoError(t, err)
+
+			// WHEN
+			httpResp, err := registry.RoundTrip(httpReq)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedAPIID, readString(httpResp.Body))
+		})
+	}
+
+	t.Run("unknown host should return error", func(t *testing.T) {
+		// GIVEN
+		httpReq, err := http.NewRequest(http.MethodGet, "https://unknown.com/api/v1/users/john.doe", http.NoBody)
+		require.NoError(t, err)
+
+		// WHEN
+		httpResp, err := registry.RoundTrip(httpReq)
+
+		// THEN
+		assert.Zero(t, httpResp)
+		assert.ErrorIs(t, err, transport.ErrHostNotRegistered)
+	})
+}
+
+func TestRegistry_RegisterAPI(t *testing.T) {
+	// GIVEN
+	created := map[string]int{}
+
+	registry := transport.NewRegistry(transport.WithClientFactory(func(region string) transport.ApiGwClient {
+		created[region]++
+
+		apiGwCli := &transporttest.Client{Region: region}
+
+		apiGwCli.
+			On("GetResources", mock.Anything).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil)
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.Anything).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(region), Status: http.StatusOK}, nil)
+
+		return apiGwCli
+	}))
+
+	for apiID, region := range map[string]string{"us3r5ap1": "eu-west-1", "0rd3r5ap1": "eu-west-1", "p4y5ap1": "ap-southeast-2"} {
+		_, err := registry.RegisterAPI(apiID, region, nil)
+		require.NoError(t, err)
+	}
+
+	_, err := registry.RegisterAPI("users", "us-east-1", []string{"users.example.com"})
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		url            string
+		expectedRegion string
+	}{
+		"eu-west-1 invoke URL": {
+			url:            "https://0rd3r5ap1.execute-api.eu-west-1.amazonaws.com/stage/api/v1/users/john.doe",
+			expectedRegion: "eu-west-1",
+		},
+		"ap-southeast-2 invoke URL": {
+			url:            "https://p4y5ap1.execute-api.ap-southeast-2.amazonaws.com/stage/api/v1/users/john.doe",
+			expectedRegion: "ap-southeast-2",
+		},
+		"custom domain": {
+			url:            "https://users.example.com/api/v1/users/john.doe",
+			expectedRegion: "us-east-1",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			httpReq, err := http.NewRequest(http.MethodGet, tc.url, http.NoBody)
+			require.NoError(t, err)
+
+			// WHEN
+			httpResp, err := registry.RoundTrip(httpReq)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedRegion, readString(httpResp.Body))
+		})
+	}
+
+	assert.Equal(t, map[string]int{"eu-west-1": 1, "ap-southeast-2": 1, "us-east-1": 1}, created)
+
+	t.Run("without client factory should return error", func(t *testing.T) {
+		// WHEN
+		tr, err := transport.NewRegistry().RegisterAPI("us3r5ap1", "eu-west-1", nil)
+
+		// THEN
+		assert.Zero(t, tr)
+		assert.ErrorIs(t, err, transport.ErrNoClientFactory)
+	})
+}
@@ -0,0 +1,166 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+)
+
+// WithRetry retries the invokes of idempotent requests (GET, HEAD, OPTIONS, PUT and DELETE) failing
+// with a transient error: throttling, API Gateway server errors, invoke timeouts or network errors.
+// A request is invoked up to maxAttempts times, waiting backoff before the first retry and twice
+// as long before each following one. The body is replayed with the request GetBody.
+//
+// The SDK client retries its calls too, these retries cover the failures left after them.
+func WithRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(t *Transport) {
+		t.retryAttempts = maxAttempts
+		t.retryBackoff = backoff
+	}
+}
+
+// retrying wraps next with the [WithRetry] retries.
+func (t *Transport) retrying(next Invoker, log *slog.Logger) Invoker {
+	if t.retryAttempts <= 1 {
+		return next
+	}
+
+	return InvokerFunc(func(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error) {
+		if !isIdempotent(r.Method) {
+			return next.Invoke(r, input)
+		}
+
+		ctx := r.Context()
+		backoff := t.retryBackoff
+
+		for attempt := 1; ; attempt++ {
+			resp, err := next.Invoke(r, input)
+			if err == nil || attempt == t.retryAttempts || ctx.Err() != nil || !isTransient(err) {
+				return resp, err
+			}
+
+			log.WarnContext(ctx, "invoke attempt failed",
+				slog.Int("attempt", attempt), slog.Any("error", err), slog.Duration("backoff", backoff))
+
+			select {
+			case <-ctx.Done():
+				return nil, err
+			case <-t.clock.After(backoff):
+			}
+
+			backoff *= 2
+			t.summary.retried()
+
+			if r.GetBody != nil {
+				if r.Body, err = r.GetBody(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	})
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isTransient reports whether an invoke failure may succeed when retried.
+func isTransient(err error) bool {
+	var invokeErr *InvokeError
+	if !errors.As(err, &invokeErr) {
+		return false
+	}
+
+	if invokeErr.StatusCode == http.StatusTooManyRequests || invokeErr.StatusCode >= http.StatusInternalServerError {
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.Is(invokeErr.Err, context.DeadlineExceeded) || errors.Is(invokeErr.Err, ErrResponseDropped) ||
+		errors.As(invokeErr.Err, &netErr)
+}
+
+// WithRetryAfter retries the requests responded with 429 Too Many Requests and a Retry-After header,
+// waiting as long as the header asks (in seconds or as a date) up to maxWait, or until the request
+// context is done. A request is sent up to maxAttempts times, whatever its method, as throttled
+// requests are not processed. The last response is returned when the attempts run out.
+func WithRetryAfter(maxAttempts int, maxWait time.Duration) Option {
+	return func(t *Transport) {
+		t.retryAfterAttempts = maxAttempts
+		t.retryAfterMaxWait = maxWait
+	}
+}
+
+// retryingAfter wraps next with the [WithRetryAfter] retries.
+func (t *Transport) retryingAfter(next Invoker, log *slog.Logger) Invoker {
+	if t.retryAfterAttempts <= 1 {
+		return next
+	}
+
+	return InvokerFunc(func(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error) {
+		ctx := r.Context()
+
+		for attempt := 1; ; attempt++ {
+			resp, err := next.Invoke(r, input)
+			if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt == t.retryAfterAttempts {
+				return resp, err
+			}
+
+			wait, found := retryAfter(resp.Header.Get("Retry-After"), t.clock.Now())
+			if !found {
+				return resp, nil
+			}
+
+			wait = min(wait, t.retryAfterMaxWait)
+
+			log.InfoContext(ctx, "throttled, retrying after",
+				slog.Int("attempt", attempt), slog.Duration("wait", wait))
+
+			_ = resp.Body.Close()
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-t.clock.After(wait):
+			}
+
+			t.summary.retried()
+
+			if r.GetBody != nil {
+				if r.Body, err = r.GetBody(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	})
+}
+
+// retryAfter parses a Retry-After header value, either delay seconds or an HTTP date.
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(date.Sub(now), 0), true
+}
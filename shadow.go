@@ -0,0 +1,142 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+)
+
+// ShadowDiff is a difference between the invoke response and the real endpoint one, see [WithShadow].
+type ShadowDiff struct {
+	Route Route
+	// Request is the request sent to both, its body can be read again with GetBody.
+	Request *http.Request
+
+	// StatusCodes are the invoke and the real response status codes.
+	StatusCodes [2]int
+	// Headers are the names of the headers with different values, volatile ones
+	// (e.g. Date or request IDs) are not compared.
+	Headers []string
+	// BodyDiffers reports whether the response bodies differ.
+	BodyDiffers bool
+
+	// Err is the real request error, the responses are not compared.
+	Err error
+}
+
+// volatileHeaders differ in every response, they are not compared.
+var volatileHeaders = []string{
+	"Date", "Via", "Connection", "Content-Length", "X-Cache",
+	"X-Amzn-Requestid", "X-Amzn-Trace-Id", "X-Amz-Apigw-Id", "X-Amz-Cf-Id", "X-Amz-Cf-Pop", "Apigw-Requestid",
+}
+
+// WithShadow sends every invoked request to the real endpoint (the request URL) through rt too,
+// e.g. a SigV4 signing or an API key adding round tripper, and calls report when the responses
+// differ in status, headers or body. The invoke response is returned, the real one is discarded.
+// Requests are sent sequentially, report is called before RoundTrip returns.
+func WithShadow(rt http.RoundTripper, report func(ShadowDiff)) Option {
+	return func(t *Transport) {
+		t.shadow = rt
+		t.shadowReport = report
+	}
+}
+
+// shadowing wraps next with the [WithShadow] comparison.
+func (t *Transport) shadowing(next Invoker, res resource) Invoker {
+	if t.shadow == nil {
+		return next
+	}
+
+	return InvokerFunc(func(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error) {
+		resp, err := next.Invoke(r, input)
+		if err != nil {
+			return resp, err
+		}
+
+		body, err := readAndReplaceBody(resp)
+		if err != nil {
+			return nil, err
+		}
+
+		diff := ShadowDiff{Route: res.route(), Request: r}
+
+		realResp, realBody, realErr := t.sendShadow(r)
+
+		switch {
+		case realErr != nil:
+			diff.Err = realErr
+		default:
+			diff.StatusCodes = [2]int{resp.StatusCode, realResp.StatusCode}
+			diff.Headers = headerDiff(resp.Header, realResp.Header)
+			diff.BodyDiffers = !bytes.Equal(body, realBody)
+		}
+
+		if diff.Err != nil || diff.StatusCodes[0] != diff.StatusCodes[1] || len(diff.Headers) > 0 || diff.BodyDiffers {
+			t.shadowReport(diff)
+		}
+
+		return resp, nil
+	})
+}
+
+func (t *Transport) sendShadow(r *http.Request) (*http.Response, []byte, error) {
+	shadow := r.Clone(r.Context())
+
+	if r.GetBody != nil {
+		body, err := r.GetBody()
+		if err != nil {
+			return nil, nil, fmt.Errorf("shadow body error: %w", err)
+		}
+
+		shadow.Body = body
+	}
+
+	resp, err := t.shadow.RoundTrip(shadow)
+	if err != nil {
+		return nil, nil, fmt.Errorf("shadow request error: %w", err)
+	}
+
+	body, err := readAndReplaceBody(resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("shadow response error: %w", err)
+	}
+
+	return resp, body, nil
+}
+
+func readAndReplaceBody(resp *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+func headerDiff(h, other http.Header) []string {
+	var names []string
+
+	for name := range h {
+		if !slices.Contains(volatileHeaders, name) && !slices.Equal(h[name], other[name]) {
+			names = append(names, name)
+		}
+	}
+
+	for name := range other {
+		if _, found := h[name]; !found && !slices.Contains(volatileHeaders, name) {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	return names
+}
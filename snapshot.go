@@ -0,0 +1,82 @@
+package transport
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// mappingsSnapshot is the JSON form of a saved mapping.
+type mappingsSnapshot struct {
+	RestAPIID string  `json:"rest_api_id"`
+	Routes    []Route `json:"routes"`
+}
+
+// SaveMappings writes the transport mapping as JSON to w, mapping the API first if needed.
+// The snapshot can be loaded with [WithMappingsFromReader] to skip GetResources on later runs.
+func (t *Transport) SaveMappings(w io.Writer) error {
+	if err := t.initMappings(context.Background()); err != nil {
+		return err
+	}
+
+	mapping := t.currentMapping()
+	snapshot := mappingsSnapshot{RestAPIID: t.apiID, Routes: mapping.routes()}
+
+	sortRoutes(snapshot.Routes)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(snapshot); err != nil {
+		return fmt.Errorf("encode mappings error: %w", err)
+	}
+
+	return nil
+}
+
+// sortRoutes sorts routes by template and method.
+func sortRoutes(routes []Route) {
+	slices.SortFunc(routes, func(a, b Route) int {
+		return cmp.Or(cmp.Compare(a.Template, b.Template), cmp.Compare(a.Method, b.Method))
+	})
+}
+
+type snapshotSource struct {
+	apiID    string
+	snapshot mappingsSnapshot
+	err      error
+}
+
+func (s snapshotSource) Name() string {
+	return "snapshot"
+}
+
+func (s snapshotSource) Routes(context.Context) ([]Route, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	if s.snapshot.RestAPIID != s.apiID {
+		return nil, fmt.Errorf("%w: snapshot of API %q", ErrSnapshotMismatch, s.snapshot.RestAPIID)
+	}
+
+	return s.snapshot.Routes, nil
+}
+
+// WithMappingsFromReader builds the mapping from a snapshot written by [Transport.SaveMappings]
+// instead of calling GetResources. The snapshot is read when the option is applied,
+// decoding errors and snapshots of other APIs fail the mapping.
+func WithMappingsFromReader(r io.Reader) Option {
+	return func(t *Transport) {
+		source := snapshotSource{apiID: t.apiID}
+
+		if err := json.NewDecoder(r).Decode(&source.snapshot); err != nil {
+			source.err = fmt.Errorf("decode mappings error: %w", err)
+		}
+
+		t.sources = []MappingSource{source}
+	}
+}
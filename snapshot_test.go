@@ -0,0 +1,69 @@
+package transport_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	transport "github.com/rcarrion2/aws-apigw-invoke-transport"
+	"github.com/rcarrion2/aws-apigw-invoke-transport/transporttest"
+)
+
+func TestSaveMappings(t *testing.T) {
+	const apiID = "abc123"
+
+	t.Run("should load saved mappings without getting resources", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		var snapshot bytes.Buffer
+
+		require.NoError(t, transport.NewTransport(apiGwCli, apiID).SaveMappings(&snapshot))
+
+		httpReq := createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody)
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.MatchedBy(matchTestInvoke(apiID, "2cb3ff", httpReq))).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+			Once()
+
+		// WHEN
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithMappingsFromReader(&snapshot))
+		httpResp, err := tr.RoundTrip(httpReq)
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.Len(t, tr.Mappings(), 5)
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("should fail with the snapshot of another API", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+		snapshot := strings.NewReader(`{"rest_api_id":"def456","routes":[]}`)
+
+		// WHEN
+		tr, err := transport.NewInitializedTransport(apiGwCli, apiID, transport.WithMappingsFromReader(snapshot))
+
+		// THEN
+		assert.Nil(t, tr)
+		assert.ErrorIs(t, err, transport.ErrSnapshotMismatch)
+		assert.EqualError(t, err, `mappings snapshot mismatch: snapshot of API "def456"`)
+
+		apiGwCli.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,441 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+)
+
+// Route is an API Gateway resource method requests can be routed to.
+type Route struct {
+	Method string `json:"method"`
+	// Template is the resource path, including path variables (e.g. /api/v1/users/{value}).
+	Template   string `json:"template"`
+	ResourceID string `json:"resource_id"`
+
+	// RequiredParameters are the method request parameters marked as required (e.g. querystring.name).
+	RequiredParameters []string `json:"required_parameters,omitempty"`
+
+	// Pattern is the regex matching the method#path of the requests, only set by [Transport.Routes].
+	Pattern string `json:"-"`
+}
+
+// MappingSource provides the routes used to build the transport mapping.
+type MappingSource interface {
+	// Name identifies the source in logs and conflict reports.
+	Name() string
+	Routes(ctx context.Context) ([]Route, error)
+}
+
+type resourcesSource struct {
+	client    ApiGwClient
+	apiID     string
+	customize func(*apigateway.GetResourcesInput)
+	// embedMethods embeds the methods, whose request parameters are only returned embedded.
+	embedMethods bool
+}
+
+// ResourcesSource is the [MappingSource] discovering routes from the live API
+// resources (GetResources), fetching every page. It is the default source.
+func ResourcesSource(client ApiGwClient, apiID string) MappingSource {
+	return resourcesSource{client: client, apiID: apiID}
+}
+
+// resourcesSource returns the resources source of an API, with the [WithGetResourcesInput] customization.
+func (t *Transport) resourcesSource(apiID string) MappingSource {
+	return resourcesSource{client: t.client, apiID: apiID, customize: t.resourcesInput}
+}
+
+// methodsEmbedder is implemented by the sources whose routes only have their required parameters
+// when the methods are embedded in the GetResources input.
+type methodsEmbedder interface {
+	embeddingMethods() MappingSource
+}
+
+func (s resourcesSource) embeddingMethods() MappingSource {
+	s.embedMethods = true
+	return s
+}
+
+func (s resourcesSource) Name() string {
+	return "resources"
+}
+
+func (s resourcesSource) Routes(ctx context.Context) ([]Route, error) {
+	input := &apigateway.GetResourcesInput{RestApiId: aws.String(s.apiID)}
+	if s.customize != nil {
+		s.customize(input)
+	}
+
+	if s.embedMethods && !slices.Contains(input.Embed, "methods") {
+		input.Embed = append(input.Embed, "methods")
+	}
+
+	var routes []Route
+
+	for {
+		resources, err := s.client.GetResources(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("get resources error: %w", err)
+		}
+
+		for _, res := range resources.Items {
+			for method, m := range res.ResourceMethods {
+				routes = append(routes, Route{
+					Method:             method,
+					Template:           *res.Path,
+					ResourceID:         *res.Id,
+					RequiredParameters: requiredParameters(m),
+				})
+			}
+		}
+
+		if aws.ToString(resources.Position) == "" {
+			return routes, nil
+		}
+
+		input.Position = resources.Position
+	}
+}
+
+// WithGetResourcesInput customizes the GetResources input of the resources discovery, e.g. to
+// embed the methods or to get bigger pages:
+//
+//	transport.WithGetResourcesInput(func(i *apigateway.GetResourcesInput) {
+//		i.Embed = []string{"methods"}
+//		i.Limit = aws.Int32(500)
+//	})
+//
+// The API ID is set before fn is called and the page position is managed by the transport.
+func WithGetResourcesInput(fn func(*apigateway.GetResourcesInput)) Option {
+	return func(t *Transport) {
+		t.resourcesInput = fn
+	}
+}
+
+type staticSource []Route
+
+// StaticSource is a [MappingSource] of fixed routes, e.g. to pin critical routes
+// with more precedence than the discovered ones.
+func StaticSource(routes ...Route) MappingSource {
+	return staticSource(routes)
+}
+
+func (s staticSource) Name() string {
+	return "static"
+}
+
+func (s staticSource) Routes(context.Context) ([]Route, error) {
+	return s, nil
+}
+
+type staticMappingsSource map[string]string
+
+func (s staticMappingsSource) Name() string {
+	return "static"
+}
+
+func (s staticMappingsSource) Routes(context.Context) ([]Route, error) {
+	routes := make([]Route, 0, len(s))
+
+	for key, resourceID := range s {
+		method, template, found := strings.Cut(key, "#")
+		if !found || method == "" || !strings.HasPrefix(template, "/") {
+			return nil, fmt.Errorf("%w: %q must have the method#path form", ErrInvalidMappingKey, key)
+		}
+
+		routes = append(routes, Route{Method: method, Template: template, ResourceID: resourceID})
+	}
+
+	return routes, nil
+}
+
+// WithStaticMappings builds the mapping from a static table instead of calling GetResources,
+// for environments where resources can not be listed but their IDs are known.
+//
+// Keys have the method#path form [Transport.Mappings] uses (e.g. GET#/api/v1/users/{value})
+// and values are the resource IDs.
+func WithStaticMappings(mappings map[string]string) Option {
+	return func(t *Transport) {
+		t.sources = []MappingSource{staticMappingsSource(mappings)}
+	}
+}
+
+// buildMapping merges the routes of all sources, sources come in precedence order:
+// when several sources declare the same method and template, the first one wins.
+// Conflicting resource IDs are logged, and fail the build when strict.
+func buildMapping(ctx context.Context, sources []MappingSource, log *slog.Logger, strict bool) (resourceMapping, error) {
+	return buildMappingConcurrently(ctx, sources, log, strict, 1)
+}
+
+// buildMappingConcurrently is [buildMapping] fetching up to concurrency sources at once,
+// the merge keeps the sources precedence whatever order they complete in.
+func buildMappingConcurrently(
+	ctx context.Context,
+	sources []MappingSource,
+	log *slog.Logger,
+	strict bool,
+	concurrency int,
+) (resourceMapping, error) {
+	sourcesRoutes, err := fetchRoutes(ctx, sources, concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping := resourceMapping{}
+	origins := map[string]string{}
+
+	for i, source := range sources {
+		for _, route := range sourcesRoutes[i] {
+			key := endpointKey(route.Method, route.Template)
+
+			if current, exists := mapping[key]; exists {
+				if current.id != route.ResourceID {
+					conflict := &MappingConflictError{
+						Key:       key,
+						Sources:   [2]string{origins[key], source.Name()},
+						Resources: [2]string{current.id, route.ResourceID},
+					}
+
+					if strict {
+						return nil, conflict
+					}
+
+					log.Warn("mapping conflict", slog.String("key", key),
+						slog.String("kept_source", origins[key]), slog.String("kept_resource_id", current.id),
+						slog.String("ignored_source", source.Name()), slog.String("ignored_resource_id", route.ResourceID))
+				}
+
+				continue
+			}
+
+			if err = mapping.add(route); err != nil {
+				return nil, err
+			}
+
+			origins[key] = source.Name()
+		}
+	}
+
+	return mapping, nil
+}
+
+// fetchRoutes returns the routes of each source, by source index. The first error cancels the other fetches.
+func fetchRoutes(ctx context.Context, sources []MappingSource, concurrency int) ([][]Route, error) {
+	routes := make([][]Route, len(sources))
+
+	if concurrency <= 1 || len(sources) == 1 {
+		for i, source := range sources {
+			var err error
+			if routes[i], err = source.Routes(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		return routes, nil
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var wg sync.WaitGroup
+
+	limit := make(chan struct{}, concurrency)
+
+	for i, source := range sources {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			select {
+			case limit <- struct{}{}:
+				defer func() { <-limit }()
+			case <-ctx.Done():
+				return
+			}
+
+			var err error
+			if routes[i], err = source.Routes(ctx); err != nil {
+				cancel(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if err := context.Cause(ctx); err != nil {
+		return nil, err
+	}
+
+	return routes, nil
+}
+
+// WithInitConcurrency fetches up to n mapping sources at once (see [WithMappingSources]) instead of one
+// after another, the mapping does not depend on the order they complete in.
+//
+// It only applies to several sources: the GetResources pages can not be fetched concurrently, as each
+// page gives the position of the next one. With a single source, the default, it has no effect and
+// a warning is logged; use [WithGetResourcesInput] with a bigger Limit to fetch fewer pages instead.
+func WithInitConcurrency(n int) Option {
+	return func(t *Transport) {
+		t.initConcurrency = n
+	}
+}
+
+// MappingConflictError is returned by strict builds when two sources map the same
+// method and template to different resources.
+type MappingConflictError struct {
+	Key string
+	// Sources are the names of the kept and the conflicting sources.
+	Sources [2]string
+	// Resources are the kept and the conflicting resource IDs.
+	Resources [2]string
+}
+
+func (e *MappingConflictError) Error() string {
+	return fmt.Sprintf("%s: %s is %s in %s source but %s in %s source",
+		ErrMappingConflict, e.Key, e.Resources[0], e.Sources[0], e.Resources[1], e.Sources[1])
+}
+
+func (e *MappingConflictError) Unwrap() error {
+	return ErrMappingConflict
+}
+
+// WithMappingSources builds the mapping from sources, in precedence order, instead of
+// the live resources only. Use [ResourcesSource] to include the live resources.
+func WithMappingSources(sources ...MappingSource) Option {
+	return func(t *Transport) {
+		t.sources = sources
+	}
+}
+
+// WithStrictMappingSources fails the mapping build with a [*MappingConflictError]
+// when sources conflict, instead of logging and keeping the higher precedence route.
+func WithStrictMappingSources() Option {
+	return func(t *Transport) {
+		t.strictSources = true
+	}
+}
+
+type prefixFilteredSource struct {
+	MappingSource
+	prefixes []string
+}
+
+func (s prefixFilteredSource) Routes(ctx context.Context) ([]Route, error) {
+	routes, err := s.MappingSource.Routes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(routes, func(route Route) bool {
+		return !slices.ContainsFunc(s.prefixes, func(prefix string) bool {
+			return route.Template == prefix || strings.HasPrefix(route.Template, prefix+"/")
+		})
+	}), nil
+}
+
+// WithPathPrefixFilter only maps the routes whose template is one of the path prefixes or is under them
+// (e.g. /api/v1/orders maps /api/v1/orders and /api/v1/orders/{id}, not /api/v1/ordersearch), so huge APIs
+// keep small mappings. The filter applies to every source, GetResources has no path filter so every page is
+// still fetched.
+func WithPathPrefixFilter(prefixes ...string) Option {
+	return func(t *Transport) {
+		for _, prefix := range prefixes {
+			t.pathPrefixes = append(t.pathPrefixes, strings.TrimRight(prefix, "/"))
+		}
+	}
+}
+
+// configured applies the transport settings to source: the methods embedding of
+// [WithRequestParametersValidation] and the [WithPathPrefixFilter] filter.
+func (t *Transport) configured(source MappingSource) MappingSource {
+	if embedder, ok := source.(methodsEmbedder); ok && t.validateParams {
+		source = embedder.embeddingMethods()
+	}
+
+	if len(t.pathPrefixes) == 0 {
+		return source
+	}
+
+	return prefixFilteredSource{MappingSource: source, prefixes: t.pathPrefixes}
+}
+
+// DeploymentGetter is implemented by clients able to get a deployment, as [*apigateway.Client] does.
+type DeploymentGetter interface {
+	GetDeployment(context.Context, *apigateway.GetDeploymentInput, ...func(*apigateway.Options)) (*apigateway.GetDeploymentOutput, error)
+}
+
+type stageSource struct {
+	client       ApiGwClient
+	apiID        string
+	stage        string
+	embedMethods bool
+}
+
+// StageSource is a [MappingSource] of the routes deployed to a stage, taken from the deployment
+// summary of the stage. Resource IDs and method settings come from the live API resources.
+//
+// The client must implement [StageGetter] and [DeploymentGetter].
+func StageSource(client ApiGwClient, apiID, stage string) MappingSource {
+	return stageSource{client: client, apiID: apiID, stage: stage}
+}
+
+func (s stageSource) Name() string {
+	return "stage:" + s.stage
+}
+
+func (s stageSource) embeddingMethods() MappingSource {
+	s.embedMethods = true
+	return s
+}
+
+func (s stageSource) Routes(ctx context.Context) ([]Route, error) {
+	stageGetter, isStageGetter := s.client.(StageGetter)
+	deploymentGetter, isDeploymentGetter := s.client.(DeploymentGetter)
+
+	if !isStageGetter || !isDeploymentGetter {
+		return nil, fmt.Errorf("%w: GetStage and GetDeployment", ErrOperationNotSupported)
+	}
+
+	stage, err := stageGetter.GetStage(ctx, &apigateway.GetStageInput{
+		RestApiId: aws.String(s.apiID),
+		StageName: aws.String(s.stage),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("get stage error: %w", err)
+	}
+
+	deployment, err := deploymentGetter.GetDeployment(ctx, &apigateway.GetDeploymentInput{
+		RestApiId:    aws.String(s.apiID),
+		DeploymentId: stage.DeploymentId,
+		Embed:        []string{"apisummary"},
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("get deployment error: %w", err)
+	}
+
+	live, err := resourcesSource{client: s.client, apiID: s.apiID, embedMethods: s.embedMethods}.Routes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []Route
+
+	for _, route := range live {
+		if _, deployed := deployment.ApiSummary[route.Template][route.Method]; deployed {
+			routes = append(routes, route)
+		}
+	}
+
+	return routes, nil
+}
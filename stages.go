@@ -0,0 +1,79 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// WithStages serves several stages of the API from one transport, keeping the stage in the routing key:
+// each stage is routed with the routes deployed to it (see [StageSource]) and invoked with its stage variables.
+// The client must implement [StageGetter] and [DeploymentGetter].
+//
+// The stage of a request is taken from the [InvokeContext] Stage, or else from the first path segment of
+// invoke URLs, only the /{stage} prefixes of the stages are stripped from them as with [WithStage].
+// Requests of other stages (or with no stage) are routed with the transport mapping.
+func WithStages(stages ...string) Option {
+	return func(t *Transport) {
+		for _, stage := range stages {
+			t.stages = append(t.stages, strings.Trim(stage, "/"))
+		}
+	}
+}
+
+// WithStageVariablesFor sets stage variables for the requests of a [WithStages] stage,
+// over the ones deployed to the stage.
+func WithStageVariablesFor(stage string, vars map[string]string) Option {
+	return func(t *Transport) {
+		if t.stagesOverrides == nil {
+			t.stagesOverrides = make(map[string]map[string]string)
+		}
+
+		t.stagesOverrides[strings.Trim(stage, "/")] = vars
+	}
+}
+
+func (t *Transport) servesStage(stage string) bool {
+	return stage != "" && slices.Contains(t.stages, stage)
+}
+
+// urlStage returns the [WithStages] stage an invoke URL points to.
+func (t *Transport) urlStage(r *http.Request) string {
+	if stage, _, found := t.cutUserRequestPath(matchingPath(r.URL)); found && t.servesStage(stage) {
+		return stage
+	}
+
+	if len(t.stages) == 0 || t.keepStage || !t.isInvokeRequest(r) {
+		return ""
+	}
+
+	for _, stage := range t.stages {
+		if _, found := cutStage(matchingPath(r.URL), stage); found {
+			return stage
+		}
+	}
+
+	return ""
+}
+
+// cutStage removes the /{stage} prefix from path.
+func cutStage(path, stage string) (string, bool) {
+	rest, found := strings.CutPrefix(path, "/"+stage)
+	if !found || (rest != "" && rest[0] != '/') {
+		return path, false
+	}
+
+	if rest == "" {
+		return "/", true
+	}
+
+	return rest, true
+}
+
+// stageMapping returns the mapping of the routes deployed to a stage, built on first use.
+func (t *Transport) stageMapping(ctx context.Context, apiID, stage string) (resourceMapping, error) {
+	return t.fetchMapping(ctx, apiID+"/"+stage, func() (resourceMapping, error) {
+		return buildMapping(ctx, []MappingSource{t.configured(StageSource(t.client, apiID, stage))}, t.initLog, t.strictSources)
+	})
+}
@@ -0,0 +1,65 @@
+package transport
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the transport runtime state, see [Transport.Stats].
+type Stats struct {
+	// Ready reports whether the mappings are initialized, see [Transport.Ready].
+	Ready bool
+	// Routes is the number of mapped routes.
+	Routes int
+	// RefreshedAt is when the mapping was last built, by the initialization or a refresh.
+	RefreshedAt time.Time
+	// RefreshErr is the error of the last refresh, nil when it succeeded.
+	RefreshErr error
+	// MatchHits and MatchMisses count the requests matching a resource and the ones matching none.
+	MatchHits   int64
+	MatchMisses int64
+	// Invocations and InvokeErrors count the TestInvokeMethod calls, and the ones failing.
+	Invocations  int64
+	InvokeErrors int64
+}
+
+type stats struct {
+	matchHits    atomic.Int64
+	matchMisses  atomic.Int64
+	invocations  atomic.Int64
+	invokeErrors atomic.Int64
+
+	mu         sync.Mutex
+	refreshErr error
+}
+
+func (s *stats) refreshed(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refreshErr = err
+}
+
+// Stats returns the transport runtime state, e.g. for health endpoints or debug dashboards.
+// The counters include the quiet routes (see [WithQuietRoutes]).
+func (t *Transport) Stats() Stats {
+	t.mu.RLock()
+	routes, refreshedAt := len(t.mapping), t.mappedAt
+	t.mu.RUnlock()
+
+	t.stats.mu.Lock()
+	refreshErr := t.stats.refreshErr
+	t.stats.mu.Unlock()
+
+	return Stats{
+		Ready:        t.Ready(),
+		Routes:       routes,
+		RefreshedAt:  refreshedAt,
+		RefreshErr:   refreshErr,
+		MatchHits:    t.stats.matchHits.Load(),
+		MatchMisses:  t.stats.matchMisses.Load(),
+		Invocations:  t.stats.invocations.Load(),
+		InvokeErrors: t.stats.invokeErrors.Load(),
+	}
+}
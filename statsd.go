@@ -0,0 +1,106 @@
+package transport
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatsD is a [Metrics] sending the recorded metrics as DogStatsD datagrams over UDP:
+//
+//   - <prefix>.invocations (counter) tagged method, route, status_class
+//   - <prefix>.invoke_duration (timer, ms) tagged method, route, status_class
+//   - <prefix>.errors (counter) tagged type
+//   - <prefix>.match_misses (counter) tagged method
+//
+// Sending is best effort, datagrams failing to send are dropped.
+type StatsD struct {
+	addr   string
+	prefix string
+
+	once sync.Once
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+var _ Metrics = (*StatsD)(nil)
+
+// NewStatsD creates a StatsD sending to addr (e.g. 127.0.0.1:8125), the metric names
+// are prefixed by prefix when not empty. The address is resolved on the first metric.
+func NewStatsD(addr, prefix string) *StatsD {
+	return &StatsD{addr: addr, prefix: strings.TrimSuffix(prefix, ".")}
+}
+
+// WithStatsD records the transport behavior as StatsD metrics sent to addr, see [StatsD].
+// The connection is closed by [Transport.Close].
+func WithStatsD(addr, prefix string) Option {
+	return WithMetrics(NewStatsD(addr, prefix))
+}
+
+func (s *StatsD) Invoked(route Route, status int, duration time.Duration) {
+	tags := []string{"method:" + route.Method, "route:" + route.Template, "status_class:" + statusClass(status)}
+	s.send("invocations", "1|c", tags)
+	s.send("invoke_duration", strconv.FormatFloat(float64(duration)/float64(time.Millisecond), 'f', -1, 64)+"|ms", tags)
+}
+
+func (s *StatsD) Failed(errType string) {
+	s.send("errors", "1|c", []string{"type:" + errType})
+}
+
+func (s *StatsD) MatchMissed(method string) {
+	s.send("match_misses", "1|c", []string{"method:" + method})
+}
+
+// Close closes the connection, metrics recorded afterwards are dropped.
+func (s *StatsD) Close() error {
+	s.once.Do(func() {})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+
+	return err
+}
+
+func (s *StatsD) send(name, value string, tags []string) {
+	s.once.Do(func() {
+		s.conn, _ = net.Dial("udp", s.addr)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return
+	}
+
+	if s.prefix != "" {
+		name = s.prefix + "." + name
+	}
+
+	for i, tag := range tags {
+		tags[i] = statsdTagReplacer.Replace(tag)
+	}
+
+	_, _ = fmt.Fprintf(s.conn, "%s:%s|#%s", name, value, strings.Join(tags, ","))
+}
+
+// statsdTagReplacer replaces the characters delimiting the datagram parts.
+var statsdTagReplacer = strings.NewReplacer("|", "_", ",", "_", "#", "_")
+
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+
+	return strconv.Itoa(status/100) + "xx"
+}
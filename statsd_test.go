@@ -0,0 +1,100 @@
+package transport_test
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rcarrion2/aws-apigw-invoke-transport"
+	"github.com/rcarrion2/aws-apigw-invoke-transport/transporttest"
+)
+
+func TestStatsD(t *testing.T) {
+	// GIVEN
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	route := transport.Route{Method: http.MethodGet, Template: "/api/v1/users/{value}", ResourceID: "2cb3ff"}
+	s := transport.NewStatsD(conn.LocalAddr().String(), "apigw.")
+	defer s.Close()
+
+	// WHEN
+	s.Invoked(route, http.StatusNotFound, 1500*time.Microsecond)
+	s.Failed("resource_not_found")
+	s.MatchMissed(http.MethodPost)
+
+	// THEN
+	var got []string
+	buf := make([]byte, 512)
+	for range 4 {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		got = append(got, string(buf[:n]))
+	}
+
+	assert.Equal(t, []string{
+		"apigw.invocations:1|c|#method:GET,route:/api/v1/users/{value},status_class:4xx",
+		"apigw.invoke_duration:1.5|ms|#method:GET,route:/api/v1/users/{value},status_class:4xx",
+		"apigw.errors:1|c|#type:resource_not_found",
+		"apigw.match_misses:1|c|#method:POST",
+	}, got)
+}
+
+func TestWithStatsD(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithStatsD(conn.LocalAddr().String(), "apigw"))
+
+	roundTrip := func() {
+		httpReq, err := http.NewRequest(http.MethodGet, "https://custom-domain.com/not/found", http.NoBody)
+		require.NoError(t, err)
+
+		_, err = tr.RoundTrip(httpReq)
+		require.ErrorIs(t, err, transport.ErrResourceNotFound)
+	}
+
+	read := func() error {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+		_, _, err := conn.ReadFrom(make([]byte, 512))
+
+		return err
+	}
+
+	// WHEN
+	roundTrip()
+	sentBeforeClose := []error{read(), read()} // errors and match_misses
+
+	closeErr := tr.Close()
+
+	roundTrip()
+	sentAfterClose := read()
+
+	// THEN
+	require.NoError(t, closeErr)
+	assert.Equal(t, []error{nil, nil}, sentBeforeClose)
+
+	var netErr net.Error
+	require.ErrorAs(t, sentAfterClose, &netErr, "no metric should be sent once closed")
+	assert.True(t, netErr.Timeout())
+
+	apiGwCli.AssertExpectations(t)
+}
@@ -0,0 +1,63 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// StatusError is returned instead of the responses whose status is an error with [WithErrorOnStatus].
+// It matches [ErrUnexpectedStatus].
+type StatusError struct {
+	Method string
+	Path   string
+	// Route is the matched route, zero for responses not created by an invoke (e.g. stubbed ones).
+	Route Route
+
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+func newStatusError(resp *http.Response) (*StatusError, error) {
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("read response body error: %w", err)
+	}
+
+	e := &StatusError{Status: resp.StatusCode, Header: resp.Header, Body: body}
+
+	if r := resp.Request; r != nil {
+		e.Method, e.Path = r.Method, r.URL.Path
+		e.Route, _ = RouteFromContext(r.Context())
+	}
+
+	return e, nil
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %s %s responded %d %s", ErrUnexpectedStatus, e.Method, e.Path, e.Status, http.StatusText(e.Status))
+}
+
+func (e *StatusError) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+// StatusCode returns the status of the response.
+func (e *StatusError) StatusCode() int {
+	return e.Status
+}
+
+// WithErrorOnStatus returns a [*StatusError] carrying the status and the body instead of the responses
+// whose status is an error for isError, e.g. for test frameworks only failing on transport errors:
+//
+//	transport.WithErrorOnStatus(func(status int) bool { return status >= 500 })
+//
+// Redirects are followed first (see [WithFollowRedirects]).
+func WithErrorOnStatus(isError func(status int) bool) Option {
+	return func(t *Transport) {
+		t.errorOnStatus = isError
+	}
+}
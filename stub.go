@@ -0,0 +1,55 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+)
+
+type stub struct {
+	routePattern
+
+	status  int
+	body    string
+	headers http.Header
+}
+
+func (s stub) output() *apigateway.TestInvokeMethodOutput {
+	return &apigateway.TestInvokeMethodOutput{
+		Status:            int32(s.status),
+		Body:              aws.String(s.body),
+		MultiValueHeaders: s.headers.Clone(),
+	}
+}
+
+type stubs []stub
+
+func (ss stubs) match(method, path string) (stub, bool) {
+	for _, s := range ss {
+		if s.match(method, path) {
+			return s, true
+		}
+	}
+
+	return stub{}, false
+}
+
+// WithStubbedResponse short-circuits the requests matching pattern with a fixed response,
+// without calling AWS. Routes not matching any stub keep invoking the API Gateway.
+//
+// The pattern has the same method#path form that [Transport.Mappings] keys use,
+// path variables included (e.g. GET#/api/v1/users/{value}), ANY patterns match every method.
+// When several stubs match a request, the first one registered is used.
+func WithStubbedResponse(pattern string, status int, body string, headers http.Header) Option {
+	p := newRoutePattern(pattern)
+
+	return func(t *Transport) {
+		t.stubs = append(t.stubs, stub{
+			routePattern: p,
+			status:       status,
+			body:         body,
+			headers:      headers,
+		})
+	}
+}
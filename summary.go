@@ -0,0 +1,236 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// runSummary collects the activity of a transport to write a JSON summary at [Transport.Close].
+//
+// A nil *runSummary is valid and records nothing.
+type runSummary struct {
+	path string
+
+	mu        sync.Mutex
+	initTime  time.Duration
+	requests  int
+	calls     int
+	retries   int
+	routes    map[string]int
+	statuses  map[int]int
+	errors    map[string]int
+	latencies []int64
+}
+
+// Summary is the JSON document written by [WithSummaryFile].
+type Summary struct {
+	Requests    int             `json:"requests"`
+	Invocations int             `json:"invocations"`
+	Routes      map[string]int  `json:"routes"`
+	Statuses    map[int]int     `json:"statuses"`
+	Errors      map[string]int  `json:"errors"`
+	LatencyMS   LatencySummary  `json:"latency_ms"`
+	Coverage    CoverageSummary `json:"coverage"`
+	Budget      BudgetSummary   `json:"budget"`
+	// InitMS is the time the mappings initialization took, in milliseconds.
+	InitMS int64 `json:"init_ms"`
+}
+
+// LatencySummary holds the invoke latency percentiles, in milliseconds, as reported by API Gateway.
+type LatencySummary struct {
+	P50 int64 `json:"p50"`
+	P90 int64 `json:"p90"`
+	P99 int64 `json:"p99"`
+	Max int64 `json:"max"`
+}
+
+// BudgetSummary holds the TestInvokeMethod calls spent from the API Gateway control plane quota,
+// the quiet routes included.
+type BudgetSummary struct {
+	// TestInvokeCalls counts the TestInvokeMethod calls, the retries included.
+	TestInvokeCalls int `json:"test_invoke_calls"`
+	// Retries counts the invokes retried by [WithRetry] and [WithRetryAfter].
+	Retries int `json:"retries"`
+}
+
+// CoverageSummary compares the routes exercised against all the routes mapped.
+type CoverageSummary struct {
+	Mapped      int      `json:"mapped"`
+	Exercised   int      `json:"exercised"`
+	Ratio       float64  `json:"ratio"`
+	Unexercised []string `json:"unexercised"`
+}
+
+func newRunSummary(path string) *runSummary {
+	return &runSummary{
+		path:     path,
+		routes:   map[string]int{},
+		statuses: map[int]int{},
+		errors:   map[string]int{},
+	}
+}
+
+func (s *runSummary) request(err error) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+
+	if err != nil {
+		s.errors[errorType(err)]++
+	}
+}
+
+func (s *runSummary) initialized(d time.Duration) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.initTime = d
+}
+
+func (s *runSummary) called() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+}
+
+func (s *runSummary) retried() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.retries++
+}
+
+func (s *runSummary) invoked(route string, status int, latency int64) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.routes[route]++
+	s.statuses[status]++
+	s.latencies = append(s.latencies, latency)
+}
+
+func (s *runSummary) build(mapping resourceMapping) Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary := Summary{
+		Requests:    s.requests,
+		Invocations: len(s.latencies),
+		Routes:      maps.Clone(s.routes),
+		Statuses:    maps.Clone(s.statuses),
+		Errors:      maps.Clone(s.errors),
+		LatencyMS:   latencySummary(s.latencies),
+		Coverage:    CoverageSummary{Mapped: len(mapping), Unexercised: []string{}},
+		Budget:      BudgetSummary{TestInvokeCalls: s.calls, Retries: s.retries},
+		InitMS:      s.initTime.Milliseconds(),
+	}
+
+	for key := range mapping {
+		if s.routes[key] > 0 {
+			summary.Coverage.Exercised++
+		} else {
+			summary.Coverage.Unexercised = append(summary.Coverage.Unexercised, key)
+		}
+	}
+
+	slices.Sort(summary.Coverage.Unexercised)
+
+	if summary.Coverage.Mapped > 0 {
+		summary.Coverage.Ratio = float64(summary.Coverage.Exercised) / float64(summary.Coverage.Mapped)
+	}
+
+	return summary
+}
+
+func (s *runSummary) write(mapping resourceMapping) error {
+	if s == nil {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.build(mapping), "", "  ")
+	if err != nil {
+		return fmt.Errorf("summary encode error: %w", err)
+	}
+
+	if err = os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("summary write error: %w", err)
+	}
+
+	return nil
+}
+
+func latencySummary(latencies []int64) LatencySummary {
+	if len(latencies) == 0 {
+		return LatencySummary{}
+	}
+
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+
+	percentile := func(p int) int64 {
+		rank := (p*len(sorted) + 99) / 100 // nearest-rank method
+		return sorted[max(rank, 1)-1]
+	}
+
+	return LatencySummary{
+		P50: percentile(50),
+		P90: percentile(90),
+		P99: percentile(99),
+		Max: sorted[len(sorted)-1],
+	}
+}
+
+// errorType classifies errors returned by the transport for summaries.
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, ErrResourceNotFound):
+		return "resource_not_found"
+	case errors.Is(err, ErrMissingRequestParameters):
+		return "missing_request_parameters"
+	case errors.Is(err, ErrTooManyRedirects):
+		return "too_many_redirects"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, ErrUnexpectedStatus):
+		return "unexpected_status"
+	default:
+		return "other"
+	}
+}
+
+// WithSummaryFile writes a JSON [Summary] of the transport activity (routes exercised, statuses,
+// latency percentiles, errors by type, TestInvokeMethod budget usage and routes coverage) to path
+// when the transport is closed.
+func WithSummaryFile(path string) Option {
+	return func(t *Transport) {
+		t.summary = newRunSummary(path)
+	}
+}
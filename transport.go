@@ -2,15 +2,22 @@ package transport
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 
@@ -18,7 +25,29 @@ import (
 )
 
 var (
-	ErrResourceNotFound = errors.New("resource not found")
+	ErrResourceNotFound         = errors.New("resource not found")
+	ErrMissingRequestParameters = errors.New("missing required request parameters")
+	ErrTooManyRedirects         = errors.New("too many redirects")
+	ErrOperationNotSupported    = errors.New("operation not supported by client")
+	ErrMappingConflict          = errors.New("mapping conflict")
+	ErrHostNotRegistered        = errors.New("host not registered")
+	ErrInvalidMappingKey        = errors.New("invalid mapping key")
+	ErrInvalidInvokeContext     = errors.New("invalid invoke context")
+	ErrSnapshotMismatch         = errors.New("mappings snapshot mismatch")
+	ErrBodyTooLarge             = errors.New("request body too large")
+	ErrNoClientFactory          = errors.New("no client factory")
+	ErrAPINotFound              = errors.New("rest api not found")
+	ErrAmbiguousAPIName         = errors.New("ambiguous rest api name")
+	ErrCircuitOpen              = errors.New("circuit open")
+	ErrResponseDropped          = errors.New("response dropped")
+	ErrNoCredentials            = errors.New("no credentials")
+	ErrValidationFailed         = errors.New("validation failed")
+	ErrMissingPathParameters    = errors.New("missing path parameters")
+	ErrUnexpectedStatus         = errors.New("unexpected status")
+	ErrMalformedProxyResponse   = errors.New("malformed lambda proxy response")
+	ErrInvalidMultipart         = errors.New("invalid multipart body")
+	// ErrIntegrationTimeout is matched by invokes timing out, it matches [context.DeadlineExceeded] too.
+	ErrIntegrationTimeout = fmt.Errorf("integration timeout: %w", context.DeadlineExceeded)
 )
 
 // ApiGwClient is an [*apigateway.Client] abstraction.
@@ -29,71 +58,819 @@ type ApiGwClient interface {
 }
 
 // Transport is a [http.RoundTripper] that map [http.Request] to [*apigateway.TestInvokeMethodInput].
+//
+// A Transport is safe for concurrent use by multiple goroutines: RoundTrip, Refresh, Mappings
+// and Close can be called in parallel, mapping swaps are atomic for in-flight requests.
+// A Transport must not be copied after first use.
 type Transport struct {
-	apiID         string
-	invokeURLHost string
-	mapping       resourceMapping
+	apiID              string
+	invokeURLHosts     []string
+	invokeHostPatterns []*regexp.Regexp
+	stage              string
+	keepStage          bool
+	forwardedHost      bool
+	localStack         bool
+
+	mu         sync.RWMutex
+	mapping    resourceMapping
+	mappingLog *mappingLog
+	mappedAt   time.Time
+	mappingTTL time.Duration
+	refreshing atomic.Bool
+
+	onRefresh           func(RouteDiff)
+	missRefresh         bool
+	missRefreshInterval time.Duration
+	missRefreshMu       sync.Mutex
+	missRefreshedAt     time.Time
+
+	sources         []MappingSource
+	strictSources   bool
+	resourcesInput  func(*apigateway.GetResourcesInput)
+	pathPrefixes    []string
+	initConcurrency int
+
+	overridesMu      sync.Mutex
+	overridesFlights flightGroup
+	apiMappings      map[string]resourceMapping
+	apiMappingLogs   map[string]*mappingLog
+	stagesVariables  map[string]map[string]string
+	stagesOverrides  map[string]map[string]string
+	stages           []string
+	resolveBasePaths bool
+	customDomains    map[string][]basePathMapping
+
+	headFallback       bool
+	trailingSlash      bool
+	validateParams     bool
+	stubs              stubs
+	maxRedirects       int
+	registry           atomic.Pointer[Registry] // the registry the transport is registered in, to follow redirects to its hosts.
+	errorOnStatus      func(int) bool
+	summary            *runSummary
+	metrics            Metrics
+	keepEmptyQuery     bool
+	stageVariables     map[string]string
+	slowInvoke         time.Duration
+	retryAttempts      int
+	retryAfterAttempts int
+	retryAfterMaxWait  time.Duration
+	breaker            *breaker
+	cache              CacheStore
+	shadow             http.RoundTripper
+	shadowReport       func(ShadowDiff)
+	faults             *faults
+	direct             *DirectFallback
+	retryBackoff       time.Duration
+	invokeTimeout      time.Duration
+	headerFilter       *HeaderFilter
+	maxBodySize        int64
+	decompress         bool
+	interceptors       []Interceptor
+	lambdaRoutes       []lambdaRoute
+	invokers           []routeInvoker
+	inputModifiers     []func(context.Context, *apigateway.TestInvokeMethodInput) error
+	responseModifiers  []func(context.Context, *http.Response, *apigateway.TestInvokeMethodOutput) error
+
+	clientCertID    string
+	clientCertStage string
+
+	binaryMediaTypes []string
+	quietRoutes      routePatterns
+
+	client   ApiGwClient
+	clock    Clock
+	log      *slog.Logger
+	initLog  *slog.Logger
+	quietLog *slog.Logger
+	logAttrs []any
+
+	requestLogAttrs []any
+
+	payloadLog   PayloadLog
+	logBodyLimit int
+	logMappings  bool
+	accessLog    bool
+
+	stats stats
+
+	initMu    sync.Mutex
+	initRunMu sync.Mutex
+	initCall  *initCall
+	initDone  atomic.Bool
+	initErr   error
+
+	initFailures    int
+	initRetryAt     time.Time
+	initMaxAttempts int
+	initBackoff     time.Duration
 
-	client  ApiGwClient
-	log     *slog.Logger
-	once    *sync.Once
-	initErr error
+	asyncInit      bool
+	asyncInitReady func(error)
 }
 
 func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := t.clock.Now()
+	resp, err := t.followRoundTrip(r)
+
+	if err == nil && t.errorOnStatus != nil && t.errorOnStatus(resp.StatusCode) {
+		var statusErr *StatusError
+		if statusErr, err = newStatusError(resp); err == nil {
+			err = statusErr
+		}
+
+		resp = nil
+	}
+
+	if !t.isQuiet(r.Method, t.requestPath(r)) {
+		if t.accessLog {
+			t.logAccess(r, resp, err, t.clock.Now().Sub(start))
+		}
+
+		t.summary.request(err)
+
+		if err != nil {
+			t.metrics.Failed(errorType(err))
+		}
+	}
+
+	return resp, err
+}
+
+func (t *Transport) followRoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := t.roundTrip(r)
+
+	for redirects, current := 0, t; err == nil && t.maxRedirects > 0; redirects++ {
+		next, to, redirectErr := current.redirectRequest(resp)
+		if redirectErr != nil {
+			return nil, redirectErr
+		}
+
+		if next == nil {
+			break
+		}
+
+		if redirects == t.maxRedirects {
+			return nil, fmt.Errorf("%w: stopped after %d redirects", ErrTooManyRedirects, t.maxRedirects)
+		}
+
+		_ = resp.Body.Close()
+
+		t.requestLog(r.Context()).DebugContext(r.Context(), "following redirect",
+			slog.Int("status", resp.StatusCode), slog.String("location", next.URL.String()))
+
+		current = to
+		resp, err = current.roundTrip(next)
+	}
+
+	return resp, err
+}
+
+func (t *Transport) roundTrip(r *http.Request) (*http.Response, error) {
 	ctx := r.Context()
+	path := t.requestPath(r)
+
+	quiet := t.isQuiet(r.Method, path)
+	log := t.requestLog(ctx)
 
-	if err := t.initMappings(); err != nil {
+	if quiet {
+		log = t.quietLog
+	}
+
+	if s, stubbed := t.stubs.match(r.Method, path); stubbed {
+		log.DebugContext(ctx, "stubbed response", slog.String("pattern", s.pattern))
+		return createHTTPResponse(r, s.output()), nil
+	}
+
+	if t.sendsDirect(r) {
+		return t.directRoundTrip(r, log)
+	}
+
+	if err := t.initMappings(ctx); err != nil {
 		return nil, err
 	}
 
-	t.log.DebugContext(ctx, "resources mapped", "resources", t.mapping)
+	t.refreshIfExpired()
 
-	path := r.URL.Path
-	if isInvokeURL(r.URL, t.invokeURLHost) {
-		path = removeStagePathPart(path)
+	ic, hasInvokeContext := InvokeContextFromContext(ctx)
+	if stage := t.urlStage(r); stage != "" && ic.Stage == "" {
+		ic.Stage, hasInvokeContext = stage, true
 	}
 
-	resourceID, hasResource := t.mapping.matchResourceID(r.Method, path)
-	if !hasResource {
-		return nil, ErrResourceNotFound
+	if hasInvokeContext {
+		if err := ic.validate(t.client); err != nil {
+			return nil, err
+		}
+	}
+
+	stage := ic.Stage
+
+	apiID, path, res, err := t.matchRequest(ctx, r, &ic, path, log)
+	if err != nil {
+		if errors.Is(err, ErrResourceNotFound) {
+			t.stats.matchMisses.Add(1)
+
+			if !quiet {
+				t.metrics.MatchMissed(r.Method)
+			}
+		}
+
+		return nil, err
+	}
+
+	t.stats.matchHits.Add(1)
+
+	if ic.Stage != stage { // the stage of the base path mapping
+		if err := ic.validate(t.client); err != nil {
+			return nil, err
+		}
+
+		hasInvokeContext = true
+	}
+
+	if hasInvokeContext {
+		ctx = NewInvokeContext(ctx, ic)
+	}
+
+	if t.validateParams {
+		if err := validateRequiredParameters(r, res, path); err != nil {
+			return nil, err
+		}
+	}
+
+	r, body, err := bufferBody(r)
+	if err != nil {
+		return nil, fmt.Errorf("create invoke input error: %w", err)
+	}
+
+	input, err := t.createInvokeInput(r, body, res, path)
+	if t.direct != nil && errors.As(err, new(*BodyTooLargeError)) {
+		return t.directRoundTrip(r, log)
 	}
 
-	input, err := createInvokeInput(r, t.apiID, resourceID, path)
 	if err != nil {
 		return nil, fmt.Errorf("create invoke input error: %w", err)
 	}
 
-	t.log.DebugContext(ctx, "invoke input created", invokeInputLogGroup(input))
+	input.RestApiId = aws.String(apiID)
+
+	if isHeadFallback(r, res) {
+		input.HttpMethod = aws.String(http.MethodGet)
+	}
+
+	var optFns []func(*apigateway.Options)
+
+	if hasInvokeContext {
+		if optFns, err = t.applyInvokeContext(ctx, ic, input); err != nil {
+			return nil, err
+		}
+	}
+
+	params := res.pathParameters(path)
+
+	ctx = ContextWithRoute(ctx, res.route())
+	ctx = context.WithValue(ctx, pathParametersContextKey{}, params)
+	r = r.WithContext(ctx)
+
+	if log.Enabled(ctx, slog.LevelDebug) { // the log group copies the body
+		log.DebugContext(ctx, "invoke input created", t.invokeInputLogGroup(input))
+	}
+
+	var invoker Invoker = InvokerFunc(func(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error) {
+		return t.invoke(r, input, res, log, quiet, optFns)
+	})
+
+	if routeInvoker, found := t.routeInvoker(r.Method, res); found {
+		invoker = routeInvoker
+	}
+
+	for i := len(t.interceptors) - 1; i >= 0; i-- {
+		invoker = t.interceptors[i](invoker)
+	}
+
+	// the transport features wrap the interceptors, innermost first
+	invoker = t.shadowing(invoker, res)
+	invoker = t.faulting(invoker, res, path)
+	invoker = t.retrying(invoker, log)
+	invoker = t.retryingAfter(invoker, log)
+	invoker = t.breaking(invoker, res)
+	invoker = t.caching(invoker, ic)
+
+	return invoker.Invoke(r, input)
+}
+
+// invoke calls TestInvokeMethod for the matched resource, it is the innermost [Invoker].
+func (t *Transport) invoke(
+	r *http.Request,
+	input *apigateway.TestInvokeMethodInput,
+	res resource,
+	log *slog.Logger,
+	quiet bool,
+	optFns []func(*apigateway.Options),
+) (*http.Response, error) {
+	ctx := r.Context()
+	start := t.clock.Now()
+
+	invokeCtx := ctx
+
+	if t.invokeTimeout > 0 {
+		var cancel context.CancelFunc
+
+		invokeCtx, cancel = context.WithTimeout(ctx, t.invokeTimeout)
+		defer cancel()
+	}
+
+	for _, modify := range t.inputModifiers {
+		if err := modify(invokeCtx, input); err != nil {
+			return nil, fmt.Errorf("input modifier error: %w", err)
+		}
+	}
+
+	t.stats.invocations.Add(1)
+
+	var (
+		out       *apigateway.TestInvokeMethodOutput
+		invokeErr error
+	)
+
+	if lr, found := t.lambdaRoute(r.Method, res); found {
+		out, invokeErr = t.invokeLambda(invokeCtx, r, input, res, lr)
+	} else {
+		t.summary.called()
+		out, invokeErr = t.client.TestInvokeMethod(invokeCtx, input, optFns...)
+	}
 
-	out, invokeErr := t.client.TestInvokeMethod(ctx, input)
 	if invokeErr != nil {
-		return nil, fmt.Errorf("invoke error: %w", invokeErr)
+		t.stats.invokeErrors.Add(1)
+
+		return nil, newInvokeError(*input.RestApiId, res, r.Method, *input.PathWithQueryString, invokeErr)
+	}
+
+	duration := t.clock.Now().Sub(start)
+
+	if !quiet {
+		t.summary.invoked(endpointKey(res.method, res.path), int(out.Status), out.Latency)
+		t.metrics.Invoked(res.route(), int(out.Status), duration)
+	}
+
+	if log.Enabled(ctx, slog.LevelDebug) {
+		params, _ := PathParametersFromContext(ctx)
+		log.DebugContext(ctx, "invoke success", t.invokeOutputLogGroup(out), slog.Duration("duration", duration),
+			slog.Any("path_parameters", params))
+	}
+
+	if t.slowInvoke > 0 && duration > t.slowInvoke {
+		log.WarnContext(ctx, "slow invoke",
+			slog.String("resource_id", res.id), slog.Duration("duration", duration), slog.Duration("threshold", t.slowInvoke))
+	}
+
+	ctx = context.WithValue(ctx, invokeLatencyContextKey{}, time.Duration(out.Latency)*time.Millisecond)
+	ctx = context.WithValue(ctx, invokeOutputContextKey{}, out)
+	resp := createHTTPResponse(r.WithContext(ctx), out)
+
+	if t.decompress {
+		if err := decompressBody(resp); err != nil {
+			return nil, err
+		}
 	}
 
-	t.log.DebugContext(ctx, "invoke success", invokeOutputLogGroup(out))
+	if isHeadFallback(r, res) {
+		resp.Body = http.NoBody // HEAD responses have no body, the GET one's length is kept
+	}
+
+	for _, modify := range t.responseModifiers {
+		if err := modify(ctx, resp, out); err != nil {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("response modifier error: %w", err)
+		}
+	}
 
-	return createHTTPResponse(r, out), nil
+	return resp, nil
 }
 
-func (t *Transport) initMappings() error {
-	t.once.Do(func() {
-		t.log.Debug("initializing endpoint mappings")
-		t.mapping, t.initErr = mapEndpointResources(t.client, t.apiID)
-		t.log.Debug("mappings ready")
-	})
+// matchRequest finds the API and the resource a request is routed to, returning the path
+// the resource is matched with (e.g. without the custom domain base path).
+// A [*ResourceNotFoundError] is returned when no resource matches.
+func (t *Transport) matchRequest(
+	ctx context.Context,
+	r *http.Request,
+	ic *InvokeContext,
+	path string,
+	log *slog.Logger,
+) (string, string, resource, error) {
+	apiID, mapping := t.apiID, t.currentMapping()
+
+	if t.resolveBasePaths && !t.isInvokeRequest(r) {
+		var (
+			stage string
+			err   error
+		)
+
+		if apiID, stage, path, err = t.resolveBasePath(ctx, t.requestHost(r), path); err != nil {
+			return "", "", resource{}, err
+		}
+
+		if stage != "" && stage != t.stage && ic.Stage == "" {
+			ic.Stage = stage
+		}
+	}
+
+	if ic.APIID != "" {
+		apiID = ic.APIID
+	}
+
+	mappingKey := apiID
+
+	switch {
+	case t.servesStage(ic.Stage):
+		var err error
+
+		mappingKey = apiID + "/" + ic.Stage
+
+		if mapping, err = t.stageMapping(ctx, apiID, ic.Stage); err != nil {
+			return "", "", resource{}, err
+		}
+	case apiID != t.apiID:
+		var err error
+
+		if mapping, err = t.apiMapping(ctx, apiID); err != nil {
+			return "", "", resource{}, err
+		}
+	}
+
+	if t.logMappings {
+		log.DebugContext(ctx, "resources mapped", "resources", t.mappingLogOf(mappingKey, mapping))
+	}
+
+	if id, pinned := resourceIDFromContext(ctx); pinned {
+		log.DebugContext(ctx, "resource pinned", slog.String("resource_id", id))
+		return apiID, path, mapping.byID(id, r.Method, path), nil
+	}
+
+	res, matchedPath, hasResource := t.matchPath(mapping, r.Method, path)
+	if !hasResource && t.missRefresh && mappingKey == t.apiID {
+		t.refreshOnMiss(ctx)
+		mapping = t.currentMapping()
+		res, matchedPath, hasResource = t.matchPath(mapping, r.Method, path)
+	}
+
+	if !hasResource {
+		return "", "", resource{}, &ResourceNotFoundError{
+			Method:     r.Method,
+			Path:       path,
+			APIID:      apiID,
+			Candidates: mapping.candidates(r.Method, path),
+		}
+	}
+
+	return apiID, matchedPath, res, nil
+}
+
+// matchPath matches the resource of a request and returns the path it matched, without
+// the trailing slashes with [WithTrailingSlashEquivalence].
+func (t *Transport) matchPath(mapping resourceMapping, method, path string) (resource, string, bool) {
+	res, found := t.matchMethod(mapping, method, path)
+	if found || !t.trailingSlash {
+		return res, path, found
+	}
+
+	trimmed := cmp.Or(strings.TrimRight(path, "/"), "/")
+	if trimmed == path {
+		return res, path, false
+	}
+
+	res, found = t.matchMethod(mapping, method, trimmed)
+
+	return res, trimmed, found
+}
+
+// matchMethod matches the resource of a request, HEAD requests match GET resources with [WithHeadFallback].
+func (t *Transport) matchMethod(mapping resourceMapping, method, path string) (resource, bool) {
+	res, found := mapping.match(method, path)
+	if !found && t.headFallback && method == http.MethodHead {
+		return mapping.match(http.MethodGet, path)
+	}
+
+	return res, found
+}
+
+// isHeadFallback reports whether a HEAD request is routed to a GET resource.
+func isHeadFallback(r *http.Request, res resource) bool {
+	return r.Method == http.MethodHead && res.method == http.MethodGet
+}
+
+// MatchResult is the routing of a request, see [Transport.Match].
+type MatchResult struct {
+	APIID string
+	Route Route
+	// PathWithQueryString is the path the invoke would be called with.
+	PathWithQueryString string
+	// PathParameters are the values of the route template variables.
+	PathParameters map[string]string
+}
+
+// Match is a dry run of [Transport.RoundTrip]: it returns the resource r would be routed to
+// without invoking it, or [ErrResourceNotFound]. Stubbed responses are not considered.
+func (t *Transport) Match(r *http.Request) (MatchResult, error) {
+	ctx := r.Context()
+
+	if err := t.initMappings(ctx); err != nil {
+		return MatchResult{}, err
+	}
+
+	ic, _ := InvokeContextFromContext(ctx)
+	if stage := t.urlStage(r); stage != "" && ic.Stage == "" {
+		ic.Stage = stage
+	}
+
+	apiID, path, res, err := t.matchRequest(ctx, r, &ic, t.requestPath(r), t.requestLog(ctx))
+	if err != nil {
+		return MatchResult{}, err
+	}
+
+	return MatchResult{
+		APIID:               apiID,
+		Route:               res.route(),
+		PathWithQueryString: pathWithQueryString(path, r.URL, t.keepEmptyQuery),
+		PathParameters:      res.pathParameters(path),
+	}, nil
+}
+
+// MatchResource returns the route a request with method and path (without the stage or the custom domain
+// base path) is routed to, with the same matching [Transport.RoundTrip] uses. It only looks up the transport
+// mapping as it is, false until the mappings are initialized, see [Transport.Match] to route whole requests.
+func (t *Transport) MatchResource(method, path string) (Route, bool) {
+	res, _, found := t.matchPath(t.currentMapping(), method, path)
+	if !found {
+		return Route{}, false
+	}
+
+	return res.route(), true
+}
+
+func (t *Transport) isQuiet(method, path string) bool {
+	return len(t.quietRoutes) > 0 && t.quietRoutes.match(method, path)
+}
+
+// requestPath returns the path used to match resources.
+func (t *Transport) requestPath(r *http.Request) string {
+	path := matchingPath(r.URL)
+
+	if _, rest, found := t.cutUserRequestPath(path); found {
+		return rest
+	}
+
+	if t.keepStage || !t.isInvokeRequest(r) {
+		return path
+	}
+
+	if t.stage == "" && len(t.stages) == 0 {
+		return removeStagePathPart(path)
+	}
+
+	if t.stage != "" {
+		if rest, found := cutStage(path, t.stage); found {
+			return rest
+		}
+	}
+
+	for _, stage := range t.stages {
+		if rest, found := cutStage(path, stage); found {
+			return rest
+		}
+	}
+
+	return path
+}
+
+// matchingPath returns the decoded path of u, except for the encoded slashes (%2F) which are kept
+// encoded, as API Gateway does: an encoded slash belongs to its segment and never splits it, so
+// /users/john%2Fdoe matches /users/{value} and is invoked with the value john%2Fdoe.
+// Other escapes are decoded (e.g. /users/jos%C3%A9 is /users/josé), in both literal segments and values,
+// the decoded ? and # are escaped again in the invoke path (see [pathWithQueryString]).
+func matchingPath(u *url.URL) string {
+	escaped := u.EscapedPath()
+	if !strings.Contains(escaped, "%2F") && !strings.Contains(escaped, "%2f") {
+		return u.Path
+	}
+
+	segments := strings.Split(escaped, "/")
+
+	for i, segment := range segments {
+		if decoded, err := url.PathUnescape(segment); err == nil {
+			segments[i] = strings.ReplaceAll(decoded, "/", "%2F")
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// Stage returns the stage set with [WithStage], empty when the stage is not known.
+func (t *Transport) Stage() string {
+	return t.stage
+}
+
+// initCall is an initialization in flight, shared by the callers waiting for it.
+type initCall struct {
+	done    chan struct{}
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// initMappings initializes the transport once. Concurrent callers share a single initialization
+// and each one stops waiting when its ctx is done, the initialization is canceled once no caller
+// waits for it. Failures caused by the cancellation are not kept, so the following requests retry.
+// Other failures are retried by the requests after a backoff, see [WithInitRetry].
+func (t *Transport) initMappings(ctx context.Context) error {
+	if t.initDone.Load() {
+		return t.initErr
+	}
+
+	t.initMu.Lock()
+
+	if t.initDone.Load() {
+		t.initMu.Unlock()
+		return t.initErr
+	}
+
+	call := t.initCall
+	if call == nil && t.initErr != nil && t.clock.Now().Before(t.initRetryAt) {
+		t.initMu.Unlock()
+		return t.initErr
+	}
+
+	if call == nil {
+		initCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &initCall{done: make(chan struct{}), cancel: cancel}
+		t.initCall = call
+
+		go t.runInit(initCtx, call)
+	} else {
+		t.initLog.DebugContext(ctx, "waiting for the mappings initialization in flight")
+	}
+
+	call.waiters++
+	t.initMu.Unlock()
 
-	return t.initErr
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		t.initMu.Lock()
+		defer t.initMu.Unlock()
+
+		if call.waiters--; call.waiters == 0 {
+			call.cancel()
+
+			if t.initCall == call {
+				t.initCall = nil
+			}
+		}
+
+		return ctx.Err()
+	}
+}
+
+func (t *Transport) runInit(ctx context.Context, call *initCall) {
+	defer call.cancel()
+
+	t.initRunMu.Lock()
+	err := t.initialize(ctx)
+	t.initRunMu.Unlock()
+
+	t.initMu.Lock()
+	defer t.initMu.Unlock()
+
+	switch {
+	case err == nil:
+		t.initErr = nil
+		t.initDone.Store(true)
+	case ctx.Err() == nil:
+		t.initFailed(ctx, err)
+	}
+
+	if t.initCall == call {
+		t.initCall = nil
+	}
+
+	call.err = err
+	close(call.done)
+}
+
+func (t *Transport) initialize(ctx context.Context) error {
+	t.initLog.DebugContext(ctx, "initializing endpoint mappings")
+
+	start := t.clock.Now()
+
+	mapping, err := t.buildMapping(ctx)
+	if err != nil {
+		return err
+	}
+
+	duration := t.clock.Now().Sub(start)
+
+	t.setMapping(mapping)
+	t.summary.initialized(duration)
+	t.initLog.DebugContext(ctx, "mappings ready", slog.Int("routes", len(mapping)), slog.Duration("duration", duration))
+
+	if t.clientCertID == "" && t.clientCertStage != "" {
+		if t.clientCertID, err = stageClientCertificateID(ctx, t.client, t.apiID, t.clientCertStage); err != nil {
+			return err
+		}
+
+		t.initLog.DebugContext(ctx, "client certificate discovered",
+			slog.String("stage", t.clientCertStage), slog.String("client_certificate_id", t.clientCertID))
+	}
+
+	return nil
+}
+
+// Close releases the transport resources, writing the run summary when configured with [WithSummaryFile]
+// and closing the [WithMetrics] metrics implementing [io.Closer] (e.g. the [WithStatsD] ones).
+func (t *Transport) Close() error {
+	err := t.summary.write(t.currentMapping())
+
+	if closer, ok := t.metrics.(io.Closer); ok {
+		err = errors.Join(err, closer.Close())
+	}
+
+	return err
+}
+
+func (t *Transport) buildMapping(ctx context.Context) (resourceMapping, error) {
+	return buildMappingConcurrently(ctx, t.sources, t.initLog, t.strictSources, t.initConcurrency)
+}
+
+func (t *Transport) currentMapping() resourceMapping {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.mapping
+}
+
+func (t *Transport) setMapping(mapping resourceMapping) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.mapping = mapping
+	t.mappingLog = &mappingLog{mapping: mapping}
+	t.mappedAt = t.clock.Now()
+}
+
+// mappingLogOf returns the log value of the current mapping of an API (or API stage), rendered once per mapping.
+func (t *Transport) mappingLogOf(key string, mapping resourceMapping) *mappingLog {
+	if key == t.apiID {
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+
+		if t.mappingLog != nil {
+			return t.mappingLog
+		}
+
+		return &mappingLog{mapping: mapping}
+	}
+
+	t.overridesMu.Lock()
+	defer t.overridesMu.Unlock()
+
+	l, found := t.apiMappingLogs[key]
+	if !found {
+		l = &mappingLog{mapping: mapping}
+		t.apiMappingLogs[key] = l
+	}
+
+	return l
+}
+
+// Routes returns the routes mapped, sorted by template and method, with the Pattern they are matched with.
+// It is empty until the mappings are initialized.
+func (t *Transport) Routes() []Route {
+	mapping := t.currentMapping()
+	routes := make([]Route, 0, len(mapping))
+
+	for _, r := range mapping {
+		route := r.route()
+		route.Pattern = r.regex.String()
+		routes = append(routes, route)
+	}
+
+	sortRoutes(routes)
+
+	return routes
 }
 
 // Mappings returns a representation of all resources mapped.
 //
 // The key is formed by method#path (e.g. POST#/path/to/resource).
 // And the value is a regex to match with endpoint from the HTTP request.
+//
+// Deprecated: use [Transport.Routes], whose fields do not need to be parsed.
 func (t *Transport) Mappings() map[string]string {
-	result := make(map[string]string, len(t.mapping))
+	mapping := t.currentMapping()
+	result := make(map[string]string, len(mapping))
 
-	for k, r := range t.mapping {
+	for k, r := range mapping {
 		result[k] = fmt.Sprintf("%s->%s", r.id, r.regex.String())
 	}
 
@@ -102,39 +879,169 @@ func (t *Transport) Mappings() map[string]string {
 
 func NewTransport(client ApiGwClient, apiID string, opts ...Option) *Transport {
 	t := &Transport{
-		apiID:         apiID,
-		invokeURLHost: invokeURLHost(client, apiID),
+		apiID:          apiID,
+		invokeURLHosts: invokeURLHosts(client, apiID),
+
+		initBackoff:      defaultInitBackoff,
+		binaryMediaTypes: slices.Clone(defaultBinaryMediaTypes),
+		apiMappings:      map[string]resourceMapping{},
+		apiMappingLogs:   map[string]*mappingLog{},
+		stagesVariables:  map[string]map[string]string{},
 
-		client: client,
-		log:    nopLogger(),
-		once:   new(sync.Once),
+		client:   client,
+		clock:    systemClock{},
+		metrics:  nopMetrics{},
+		log:      nopLogger(),
+		initLog:  nopLogger(),
+		quietLog: nopLogger(),
 	}
 
 	for _, opt := range opts {
 		opt(t)
 	}
 
-	t.log = t.log.With(slog.String("rest_api_id", t.apiID))
+	if len(t.sources) == 0 {
+		t.sources = []MappingSource{t.resourcesSource(apiID)}
+	}
+
+	sources := make([]MappingSource, len(t.sources))
+	for i, source := range t.sources {
+		sources[i] = t.configured(source)
+	}
+
+	t.sources = sources
+
+	t.requestLogAttrs = []any{slog.String("rest_api_id", t.apiID)}
+
+	if t.stage != "" {
+		t.requestLogAttrs = append(t.requestLogAttrs, slog.String("stage", t.stage))
+	}
+
+	t.requestLogAttrs = append(t.requestLogAttrs, t.logAttrs...)
+	t.log = t.log.With(t.requestLogAttrs...)
+	t.initLog = t.initLog.With(slog.String("rest_api_id", t.apiID))
+
+	if len(t.logAttrs) > 0 {
+		t.initLog = t.initLog.With(t.logAttrs...)
+	}
+
+	if t.initConcurrency > 1 && len(t.sources) < 2 {
+		t.initLog.Warn("init concurrency has no effect with a single mapping source",
+			slog.Int("init_concurrency", t.initConcurrency))
+	}
+
+	if t.asyncInit {
+		t.startAsyncInit()
+	}
 
 	return t
 }
 
+// NewTransportFromConfig creates the transport with an API Gateway client of cfg, so its region and
+// credentials (e.g. loaded with config.LoadDefaultConfig for a profile) are used.
+// The client calls the cfg BaseEndpoint when set (e.g. AWS_ENDPOINT_URL), a local endpoint
+// (e.g. [LocalStackEndpoint]) enables [WithLocalStack].
+func NewTransportFromConfig(cfg aws.Config, apiID string, opts ...Option) *Transport {
+	if cfg.BaseEndpoint != nil && isLocalEndpoint(*cfg.BaseEndpoint) {
+		opts = append([]Option{WithLocalStack()}, opts...)
+	}
+
+	return NewTransport(apigateway.NewFromConfig(cfg), apiID, opts...)
+}
+
 func NewInitializedTransport(client ApiGwClient, apiID string, opts ...Option) (*Transport, error) {
+	return NewInitializedTransportContext(context.Background(), client, apiID, opts...)
+}
+
+// NewInitializedTransportContext is [NewInitializedTransport] bounding the initialization with ctx.
+func NewInitializedTransportContext(ctx context.Context, client ApiGwClient, apiID string, opts ...Option) (*Transport, error) {
 	t := NewTransport(client, apiID, opts...)
 
-	if err := t.initMappings(); err != nil {
+	if err := t.initMappings(ctx); err != nil {
 		return nil, err
 	}
 
 	return t, nil
 }
 
-func invokeURLHost(c ApiGwClient, apiID string) string {
-	return fmt.Sprintf("%s.execute-api.%s.amazonaws.com", apiID, c.Options().Region)
+func invokeURLHosts(c ApiGwClient, apiID string) []string {
+	return regionInvokeURLHosts(apiID, c.Options().Region)
 }
 
-func isInvokeURL(requestURL *url.URL, invokeHost string) bool {
-	return strings.Contains(requestURL.Host, invokeHost)
+// regionInvokeURLHosts returns the invoke URL hosts of the API in region, for the region partition.
+func regionInvokeURLHosts(apiID, region string) []string {
+	suffixes := partitionDNSSuffixes(region)
+	hosts := make([]string, 0, len(suffixes))
+
+	for _, suffix := range suffixes {
+		hosts = append(hosts, fmt.Sprintf("%s.execute-api.%s.%s", apiID, region, suffix))
+	}
+
+	return hosts
+}
+
+// apiIDHeader identifies the private API invoked through the DNS name of a VPC endpoint.
+const apiIDHeader = "X-Apigw-Api-Id"
+
+// isInvokeRequest reports whether r is sent to the API invoke URL, including the private API forms:
+// the {api-id}-{vpce-id}.execute-api.{region}.amazonaws.com host, and the VPC endpoint DNS name
+// with the invoke URL Host header or the x-apigw-api-id header.
+func (t *Transport) isInvokeRequest(r *http.Request) bool {
+	host := t.requestHost(r)
+
+	if t.isInvokeHost(host) || (r.Host != "" && t.isInvokeHost(r.Host)) {
+		return true
+	}
+
+	return strings.HasSuffix(host, ".vpce.amazonaws.com") && r.Header.Get(apiIDHeader) == t.apiID
+}
+
+// requestHost returns the host r is sent to without the port: the X-Forwarded-Host header
+// with [WithForwardedHost], the URL host, or r.Host when the URL has no host (e.g. server-side
+// requests forwarded by a proxy).
+func (t *Transport) requestHost(r *http.Request) string {
+	if t.forwardedHost {
+		if host, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Host"), ","); strings.TrimSpace(host) != "" {
+			return hostname(strings.TrimSpace(host))
+		}
+	}
+
+	return requestHostname(r)
+}
+
+// requestHostname returns the URL host of r without the port, or r.Host when the URL has no host.
+func requestHostname(r *http.Request) string {
+	if r.URL.Host != "" {
+		return r.URL.Hostname()
+	}
+
+	return hostname(r.Host)
+}
+
+func hostname(host string) string {
+	return strings.ToLower((&url.URL{Host: host}).Hostname())
+}
+
+func (t *Transport) isInvokeHost(host string) bool {
+	host = strings.ToLower(host)
+
+	if strings.HasPrefix(host, t.apiID+"-vpce-") && strings.Contains(host, ".execute-api.") {
+		return true
+	}
+
+	if t.isLocalStackHost(host) {
+		return true
+	}
+
+	if slices.ContainsFunc(t.invokeHostPatterns, func(pattern *regexp.Regexp) bool {
+		return pattern.MatchString(hostname(host))
+	}) {
+		return true
+	}
+
+	return slices.ContainsFunc(t.invokeURLHosts, func(invokeHost string) bool {
+		return strings.Contains(host, invokeHost)
+	})
 }
 
 // removeStagePathPart removes from URL the stage part (when use default invoke URL).
@@ -143,64 +1050,317 @@ func isInvokeURL(requestURL *url.URL, invokeHost string) bool {
 //   - original path: /{stage}/api/v1/demo
 //   - will produce:  /api/v1/demo
 func removeStagePathPart(path string) string {
-	split := strings.Split(path, "/")
-	if len(split) > 1 {
-		return "/" + strings.Join(split[2:], "/")
+	first := strings.IndexByte(path, '/')
+	if first < 0 {
+		return path
 	}
 
-	return path
+	second := strings.IndexByte(path[first+1:], '/')
+	if second < 0 {
+		return "/"
+	}
+
+	return path[first+1+second:]
 }
 
-func createInvokeInput(r *http.Request, apiID, resourceID, path string) (*apigateway.TestInvokeMethodInput, error) {
-	var body *string
+// maxBodyBufferHint bounds the buffer preallocated from the declared Content-Length in bufferBody.
+const maxBodyBufferHint = 1 << 20
 
-	if r.Body != nil && r.Body != http.NoBody {
-		buf := new(bytes.Buffer)
-		tee := io.TeeReader(r.Body, buf)
+// bufferBody reads the body of r, which is replaced by the buffered one so the caller can read it again.
+// It returns a shallow copy of r whose body can be read again with GetBody, so the transport and
+// the interceptors can follow redirects and retry. r is returned as is when it has no body.
+func bufferBody(r *http.Request) (*http.Request, []byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return r, nil, nil
+	}
 
-		bodyBytes, err := io.ReadAll(tee)
-		if err != nil {
-			return nil, fmt.Errorf("read request body error: %w", err)
-		}
+	// the declared length only sizes the buffer up to a bound, it is not trusted
+	buf := bytes.NewBuffer(make([]byte, 0, min(max(r.ContentLength, bytes.MinRead), maxBodyBufferHint)+bytes.MinRead))
+
+	_, err := buf.ReadFrom(r.Body)
+	_ = r.Body.Close()
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("read request body error: %w", err)
+	}
 
-		body = aws.String(string(bodyBytes))
-		r.Body = io.NopCloser(buf)
+	body := buf.Bytes()
+	getBody := func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
 	}
 
-	if len(r.URL.Query()) > 0 {
-		path += "?" + r.URL.RawQuery
+	r.Body, _ = getBody()
+
+	r = r.WithContext(r.Context())
+	r.Body, _ = getBody()
+	r.GetBody = getBody
+	r.ContentLength = int64(len(body))
+
+	return r, body, nil
+}
+
+// createInvokeInput creates the invoke input of r, body is the buffered body of r, nil when r has no body.
+func (t *Transport) createInvokeInput(r *http.Request, bodyBytes []byte, res resource, path string) (*apigateway.TestInvokeMethodInput, error) {
+	var body *string
+
+	if bodyBytes != nil {
+		if _, lambda := t.lambdaRoute(r.Method, res); !lambda && t.maxBodySize > 0 && int64(len(bodyBytes)) > t.maxBodySize {
+			return nil, &BodyTooLargeError{Limit: t.maxBodySize, Size: int64(len(bodyBytes))}
+		}
+
+		if err := validateMultipart(r.Header.Get("Content-Type"), bodyBytes); err != nil {
+			return nil, err
+		}
+
+		if isBinaryBody(r.Header.Get("Content-Type"), bodyBytes, t.binaryMediaTypes) {
+			body = aws.String(base64.StdEncoding.EncodeToString(bodyBytes))
+		} else {
+			body = aws.String(string(bodyBytes))
+		}
 	}
 
 	input := &apigateway.TestInvokeMethodInput{
 		HttpMethod:          aws.String(r.Method),
-		ResourceId:          aws.String(resourceID),
-		RestApiId:           aws.String(apiID),
+		ResourceId:          aws.String(res.id),
+		RestApiId:           aws.String(t.apiID),
 		Body:                body,
-		MultiValueHeaders:   r.Header,
-		PathWithQueryString: aws.String(path),
+		MultiValueHeaders:   t.headerFilter.apply(requestHeader(r, bodyBytes)),
+		PathWithQueryString: aws.String(pathWithQueryString(path, r.URL, t.keepEmptyQuery)),
+		StageVariables:      t.stageVariables,
+	}
+
+	if t.clientCertID != "" {
+		input.ClientCertificateId = aws.String(t.clientCertID)
 	}
 
 	return input, nil
 }
 
+// pathWithQueryString appends the query to path following these rules:
+//   - the ? and # of the decoded path are escaped again, so they are not read as the query or the fragment.
+//   - the fragment is always dropped, it is never sent to a server.
+//   - a non-empty raw query is forwarded verbatim (e.g. /path?a=1&a=2), keeping the encoding and
+//     the order of repeated parameters for signature-sensitive backends.
+//   - a bare "?" (empty query) is dropped, unless keepEmptyQuery is set.
+func pathWithQueryString(path string, u *url.URL, keepEmptyQuery bool) string {
+	path = reservedPathEscaper.Replace(path)
+
+	switch {
+	case u.RawQuery != "":
+		return path + "?" + u.RawQuery
+	case u.ForceQuery && keepEmptyQuery:
+		return path + "?"
+	default:
+		return path
+	}
+}
+
+// reservedPathEscaper escapes the characters of a decoded path delimiting the query and the fragment.
+var reservedPathEscaper = strings.NewReplacer("?", "%3F", "#", "%23")
+
+// requestHeader returns the headers of r to invoke with: the Content-Length of the body is set, as
+// [http.Request] holds it apart from the headers, and a Transfer-Encoding is dropped as the body is whole.
+func requestHeader(r *http.Request, body []byte) http.Header {
+	if body == nil && r.Header.Get("Transfer-Encoding") == "" {
+		return r.Header
+	}
+
+	header := r.Header.Clone()
+	header.Del("Transfer-Encoding")
+
+	if body != nil {
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+
+	return header
+}
+
 func createHTTPResponse(r *http.Request, out *apigateway.TestInvokeMethodOutput) *http.Response {
+	body := aws.ToString(out.Body)
+	header := responseHeader(out)
+
+	if header != nil {
+		// the body is whole, its headers must agree with it
+		header.Del("Transfer-Encoding")
+
+		if header.Get("Content-Length") != "" {
+			header.Set("Content-Length", strconv.Itoa(len(body)))
+		}
+	}
+
 	return &http.Response{
 		Status:        http.StatusText(int(out.Status)),
 		StatusCode:    int(out.Status),
 		Proto:         r.Proto,
 		ProtoMajor:    r.ProtoMajor,
 		ProtoMinor:    r.ProtoMinor,
-		Header:        out.MultiValueHeaders,
-		Body:          io.NopCloser(strings.NewReader(*out.Body)),
-		ContentLength: int64(len(*out.Body)),
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
 		Request:       r,
 	}
 }
 
+// responseHeader returns the invoke output headers with canonical names, so multiple values
+// (e.g. Set-Cookie) are found by [http.Header] and cookie jars whatever case the backend used.
+// The single-value headers are used when the output has no multi-value ones.
+func responseHeader(out *apigateway.TestInvokeMethodOutput) http.Header {
+	if out.MultiValueHeaders == nil && out.Headers == nil {
+		return nil
+	}
+
+	header := make(http.Header, max(len(out.MultiValueHeaders), len(out.Headers)))
+
+	for name, values := range out.MultiValueHeaders {
+		key := http.CanonicalHeaderKey(name)
+		header[key] = append(header[key], values...)
+	}
+
+	if len(out.MultiValueHeaders) == 0 {
+		for name, value := range out.Headers {
+			header.Add(name, value)
+		}
+	}
+
+	return header
+}
+
 type Option func(*Transport)
 
+// WithEmptyQueryPreserved forwards a bare "?" (e.g. /path?) in the PathWithQueryString.
+// By default an empty query is dropped. Fragments are always dropped.
+func WithEmptyQueryPreserved() Option {
+	return func(t *Transport) {
+		t.keepEmptyQuery = true
+	}
+}
+
+// WithRegion sets the region of the invoke URL host (e.g. {api-id}.execute-api.{region}.amazonaws.com),
+// by default it is the client region, which is wrong for clients using custom endpoints or another region.
+func WithRegion(region string) Option {
+	return func(t *Transport) {
+		t.invokeURLHosts = regionInvokeURLHosts(t.apiID, region)
+	}
+}
+
+// WithInvokeURLHost sets the host recognized as the API invoke URL, whose requests have the stage stripped.
+func WithInvokeURLHost(host string) Option {
+	return func(t *Transport) {
+		t.invokeURLHosts = []string{strings.ToLower(host)}
+	}
+}
+
+// WithInvokeHostPattern recognizes the hosts matching any of the patterns as API invoke URL hosts,
+// in addition to the default ones, e.g. internal DNS names fronting the API. Their requests have
+// the stage stripped. The patterns are matched with the lower case host without port.
+func WithInvokeHostPattern(patterns ...*regexp.Regexp) Option {
+	return func(t *Transport) {
+		t.invokeHostPatterns = append(t.invokeHostPatterns, patterns...)
+	}
+}
+
+// WithStage sets the stage of the API invoke URLs point to. Only the /{stage} prefix is stripped
+// from invoke URL paths, instead of the first path segment, so paths starting with
+// a segment looking like a stage are routed as they are.
+func WithStage(stage string) Option {
+	return func(t *Transport) {
+		t.stage = strings.Trim(stage, "/")
+	}
+}
+
+// WithTrailingSlashEquivalence routes the requests whose path only differs from a resource template by
+// trailing slashes (e.g. /api/v1/users/ for /api/v1/users) to the resource, invoking it with the template
+// form of the path. By default they are not found, as some APIs treat them as distinct paths.
+func WithTrailingSlashEquivalence() Option {
+	return func(t *Transport) {
+		t.trailingSlash = true
+	}
+}
+
+// WithForwardedHost detects the invoke URL and the custom domain requests by the X-Forwarded-Host
+// header when set, e.g. behind a reverse proxy. Only use it when the header comes from a trusted proxy.
+func WithForwardedHost() Option {
+	return func(t *Transport) {
+		t.forwardedHost = true
+	}
+}
+
+// WithoutStageStripping forwards the invoke URL request paths verbatim, the first segment
+// is not removed as the stage. Custom domain paths are never stripped.
+func WithoutStageStripping() Option {
+	return func(t *Transport) {
+		t.keepStage = true
+	}
+}
+
+// WithStageVariables sets the stage variables of every invoke input,
+// so integrations depending on them behave as in the deployed stage.
+func WithStageVariables(vars map[string]string) Option {
+	return func(t *Transport) {
+		t.stageVariables = vars
+	}
+}
+
+// WithLogger sets the logger for both initialization and request activity.
 func WithLogger(l *slog.Logger) Option {
 	return func(t *Transport) {
 		t.log = l
+		t.initLog = l
+	}
+}
+
+// WithInitLogger sets the logger for the mapping initialization activity only.
+func WithInitLogger(l *slog.Logger) Option {
+	return func(t *Transport) {
+		t.initLog = l
+	}
+}
+
+// WithRequestLogger sets the logger for the per-request activity only.
+// A logger of the request context (see [ContextWithLogger]) takes precedence.
+func WithRequestLogger(l *slog.Logger) Option {
+	return func(t *Transport) {
+		t.log = l
+	}
+}
+
+// requestLog returns the logger of a request activity: the [ContextWithLogger] logger with the
+// transport attributes, or the transport request logger.
+func (t *Transport) requestLog(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok && l != nil {
+		return l.With(t.requestLogAttrs...)
+	}
+
+	return t.log
+}
+
+// WithLogAttrs adds static attributes (e.g. service, team) to the initialization and request logs,
+// after rest_api_id. It applies to the loggers whatever the option order.
+func WithLogAttrs(attrs ...slog.Attr) Option {
+	return func(t *Transport) {
+		for _, attr := range attrs {
+			t.logAttrs = append(t.logAttrs, attr)
+		}
+	}
+}
+
+// WithHeadFallback routes the HEAD requests without a HEAD resource to the GET resource of the path,
+// as API Gateway does. The GET method is invoked and the response body is discarded.
+func WithHeadFallback() Option {
+	return func(t *Transport) {
+		t.headFallback = true
+	}
+}
+
+// WithRequestParametersValidation enables a local check of the request parameters marked as required
+// in the method request (querystring, header and path), mirroring API Gateway request validation.
+//
+// A request lacking any of them fails with a [*MissingParametersError] before invoking.
+// The methods are embedded in the GetResources input, as their request parameters are only returned so,
+// by the [ResourcesSource] and [StageSource] sources, whether they are the transport or [WithMappingSources] ones.
+// The [ExportSource] takes them from the export, other sources must set the Route RequiredParameters.
+func WithRequestParametersValidation() Option {
+	return func(t *Transport) {
+		t.validateParams = true
 	}
 }
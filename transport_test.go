@@ -2,23 +2,41 @@ package transport_test
 
 import (
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
+	"net/http/cookiejar"
 	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/service/apigateway"
 	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/rcarrion2/aws-apigw-invoke-transport"
+	"github.com/rcarrion2/aws-apigw-invoke-transport/transporttest"
 )
 
 func TestTransport_RoundTrip(t *testing.T) {
@@ -130,10 +148,10 @@ func TestTransport_RoundTrip(t *testing.T) {
 				test := func(t *testing.T, domain string) {
 					// GIVEN
 					httpReq := createRequest(tc.method, domain, tc.pathWithQueryString, tc.body)
-					apiGwCli := new(apiGwClientMock)
+					apiGwCli := new(transporttest.Client)
 
 					apiGwCli.
-						On("GetResources", mock.MatchedBy(matchGetResourceInputForAPI(apiID))).
+						On("GetResources", transporttest.GetResourcesFor(apiID)).
 						Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
 						Once()
 
@@ -182,6 +200,7 @@ func TestTransport_RoundTrip(t *testing.T) {
 			method              string
 			pathWithQueryString string
 			body                io.Reader
+			expectedCandidates  []string
 		}{
 			"non existent path": {
 				method:              http.MethodGet,
@@ -190,6 +209,12 @@ func TestTransport_RoundTrip(t *testing.T) {
 			"existent path but method does not exist": {
 				method:              http.MethodPost,
 				pathWithQueryString: "/api/v1/users/john.doe",
+				expectedCandidates:  []string{"DELETE /api/v1/users/{value}", "GET /api/v1/users/{value}"},
+			},
+			"existent path with trailing slash": {
+				method:              http.MethodPost,
+				pathWithQueryString: "/api/v1/users/",
+				expectedCandidates:  []string{"POST /api/v1/users"},
 			},
 		}
 
@@ -197,7 +222,7 @@ func TestTransport_RoundTrip(t *testing.T) {
 			t.Run(name, func(t *testing.T) {
 				// GIVEN
 				httpReq := createRequest(tc.method, customDomain, tc.pathWithQueryString, tc.body)
-				apiGwCli := new(apiGwClientMock)
+				apiGwCli := new(transporttest.Client)
 
 				apiGwCli.
 					On("GetResources", mock.Anything).
@@ -213,6 +238,13 @@ func TestTransport_RoundTrip(t *testing.T) {
 				assert.Zero(t, httpResp)
 				assert.ErrorIs(t, err, transport.ErrResourceNotFound)
 
+				var notFoundErr *transport.ResourceNotFoundError
+				require.ErrorAs(t, err, &notFoundErr)
+				assert.Equal(t, tc.method, notFoundErr.Method)
+				assert.Equal(t, tc.pathWithQueryString, notFoundErr.Path)
+				assert.Equal(t, apiID, notFoundErr.APIID)
+				assert.Equal(t, tc.expectedCandidates, notFoundErr.Candidates)
+
 				apiGwCli.AssertExpectations(t)
 			})
 		}
@@ -221,12 +253,12 @@ func TestTransport_RoundTrip(t *testing.T) {
 	t.Run("invoke error should return error", func(t *testing.T) {
 		// GIVEN
 		httpReq := createRequest(http.MethodGet, customDomain, "/api/v1/users/john.doe", http.NoBody)
-		apiGwCli := new(apiGwClientMock)
+		apiGwCli := new(transporttest.Client)
 
 		invokeErr := errors.New("something went wrong")
 
 		apiGwCli.
-			On("GetResources", mock.MatchedBy(matchGetResourceInputForAPI(apiID))).
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
 			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
 			Once()
 
@@ -245,18 +277,61 @@ func TestTransport_RoundTrip(t *testing.T) {
 		assert.ErrorIs(t, err, invokeErr)
 		assert.EqualError(t, err, "invoke error: something went wrong")
 
+		var typedErr *transport.InvokeError
+		require.ErrorAs(t, err, &typedErr)
+		assert.Equal(t, &transport.InvokeError{
+			APIID:      apiID,
+			ResourceID: "2cb3ff",
+			Method:     http.MethodGet,
+			Path:       "/api/v1/users/john.doe",
+			Err:        invokeErr,
+		}, typedErr)
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("invoke error should carry the response status", func(t *testing.T) {
+		// GIVEN
+		httpReq := createRequest(http.MethodGet, customDomain, "/api/v1/users/john.doe", http.NoBody)
+		apiGwCli := new(transporttest.Client)
+
+		invokeErr := &awshttp.ResponseError{ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusTooManyRequests}},
+			Err:      errors.New("too many requests"),
+		}}
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.Anything).
+			Return(nil, invokeErr).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID)
+
+		// WHEN
+		_, err := tr.RoundTrip(httpReq)
+
+		// THEN
+		var typedErr *transport.InvokeError
+		require.ErrorAs(t, err, &typedErr)
+		assert.Equal(t, http.StatusTooManyRequests, typedErr.StatusCode)
+
 		apiGwCli.AssertExpectations(t)
 	})
 
 	t.Run("get resources error should return error", func(t *testing.T) {
 		t.Run("when create transport", func(t *testing.T) {
 			// GIVEN
-			apiGwCli := new(apiGwClientMock)
+			apiGwCli := new(transporttest.Client)
 
 			getResourcesErr := errors.New("something went wrong")
 
 			apiGwCli.
-				On("GetResources", mock.MatchedBy(matchGetResourceInputForAPI(apiID))).
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
 				Return(nil, getResourcesErr).
 				Once()
 
@@ -273,12 +348,12 @@ func TestTransport_RoundTrip(t *testing.T) {
 
 		t.Run("when round trip", func(t *testing.T) {
 			// GIVEN
-			apiGwCli := new(apiGwClientMock)
+			apiGwCli := new(transporttest.Client)
 
 			getResourcesErr := errors.New("something went wrong")
 
 			apiGwCli.
-				On("GetResources", mock.MatchedBy(matchGetResourceInputForAPI(apiID))).
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
 				Return(nil, getResourcesErr).
 				Once()
 
@@ -310,10 +385,10 @@ func TestTransport_RoundTrip(t *testing.T) {
 			t.Run(name, func(t *testing.T) {
 				// GIVEN
 				httpReq := createRequest(http.MethodGet, customDomain, "/api/v1/users/john.doe", http.NoBody)
-				apiGwCli := new(apiGwClientMock)
+				apiGwCli := new(transporttest.Client)
 
 				apiGwCli.
-					On("GetResources", mock.MatchedBy(matchGetResourceInputForAPI(apiID))).
+					On("GetResources", transporttest.GetResourcesFor(apiID)).
 					Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
 					Once()
 
@@ -343,208 +418,5400 @@ func TestTransport_RoundTrip(t *testing.T) {
 	})
 }
 
-func TestTransport_Mappings(t *testing.T) {
-	// GIVEN
-	const apiID = "ortup5gufx"
+func TestTransport_RoundTrip_EncodedPath(t *testing.T) {
+	const apiID = "abc123"
 
-	apiGwCli := new(apiGwClientMock)
+	resources := append(createResources(), types.Resource{
+		Id:              aws.String("d4e5f6"),
+		Path:            aws.String("/api/v1/files/{proxy+}"),
+		PathPart:        aws.String("{proxy+}"),
+		ResourceMethods: map[string]types.Method{"GET": {}},
+	}, types.Resource{
+		Id:              aws.String("c4f3e1"),
+		Path:            aws.String("/api/v1/café"),
+		PathPart:        aws.String("café"),
+		ResourceMethods: map[string]types.Method{"GET": {}},
+	})
 
-	apiGwCli.
-		On("GetResources", mock.MatchedBy(matchGetResourceInputForAPI(apiID))).
-		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
-		Once()
+	testCases := map[string]struct {
+		method             string
+		path               string
+		expectedResourceID string
+		expectedInvokePath string
+		expectedParameters map[string]string
+	}{
+		"encoded slash should stay in its segment": {
+			method:             http.MethodGet,
+			path:               "/api/v1/users/john%2Fdoe",
+			expectedResourceID: "2cb3ff",
+			expectedInvokePath: "/api/v1/users/john%2Fdoe",
+			expectedParameters: map[string]string{"value": "john%2Fdoe"},
+		},
+		"lowercase encoded slash should be normalized": {
+			method:             http.MethodGet,
+			path:               "/api/v1/users/john%2fdoe",
+			expectedResourceID: "2cb3ff",
+			expectedInvokePath: "/api/v1/users/john%2Fdoe",
+			expectedParameters: map[string]string{"value": "john%2Fdoe"},
+		},
+		"encoded slash with other escapes should decode the others": {
+			method:             http.MethodGet,
+			path:               "/api/v1/users/jos%C3%A9%2Fdoe",
+			expectedResourceID: "2cb3ff",
+			expectedInvokePath: "/api/v1/users/josé%2Fdoe",
+			expectedParameters: map[string]string{"value": "josé%2Fdoe"},
+		},
+		"encoded non-ascii value should be decoded": {
+			method:             http.MethodGet,
+			path:               "/api/v1/users/jos%C3%A9",
+			expectedResourceID: "2cb3ff",
+			expectedInvokePath: "/api/v1/users/josé",
+			expectedParameters: map[string]string{"value": "josé"},
+		},
+		"encoded literal segment should match the template": {
+			method:             http.MethodGet,
+			path:               "/api/v1/caf%C3%A9",
+			expectedResourceID: "c4f3e1",
+			expectedInvokePath: "/api/v1/café",
+		},
+		"encoded unreserved characters should match the literal": {
+			method:             http.MethodPost,
+			path:               "/api/v1/%75sers",
+			expectedResourceID: "8143a9",
+			expectedInvokePath: "/api/v1/users",
+		},
+		"encoded question mark should stay escaped in the invoke path": {
+			method:             http.MethodGet,
+			path:               "/api/v1/users/a%3Fb",
+			expectedResourceID: "2cb3ff",
+			expectedInvokePath: "/api/v1/users/a%3Fb",
+			expectedParameters: map[string]string{"value": "a?b"},
+		},
+		"encoded hash should stay escaped in the invoke path": {
+			method:             http.MethodGet,
+			path:               "/api/v1/users/a%23b",
+			expectedResourceID: "2cb3ff",
+			expectedInvokePath: "/api/v1/users/a%23b",
+			expectedParameters: map[string]string{"value": "a#b"},
+		},
+		"reserved characters should stay escaped before the query": {
+			method:             http.MethodGet,
+			path:               "/api/v1/users/a%3Fb%23c%2Fd?x=1",
+			expectedResourceID: "2cb3ff",
+			expectedInvokePath: "/api/v1/users/a%3Fb%23c%2Fd?x=1",
+			expectedParameters: map[string]string{"value": "a?b#c%2Fd"},
+		},
+		"greedy variable should keep encoded and plain slashes": {
+			method:             http.MethodGet,
+			path:               "/api/v1/files/docs/a%2Fb.txt",
+			expectedResourceID: "d4e5f6",
+			expectedInvokePath: "/api/v1/files/docs/a%2Fb.txt",
+			expectedParameters: map[string]string{"proxy": "docs/a%2Fb.txt"},
+		},
+	}
 
-	httpTransport, err := transport.NewInitializedTransport(apiGwCli, apiID)
-	require.NoError(t, err, "initialization failed")
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			apiGwCli := new(transporttest.Client)
 
-	// WHEN
-	mappings := httpTransport.Mappings()
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: resources}, nil).
+				Once()
 
-	// THEN
-	expectedMappings := map[string]string{
-		"GET#/api/v1/users/{value}":    "2cb3ff->^GET#/api/v1/users/([^/]+)$",
-		"DELETE#/api/v1/users/{value}": "2cb3ff->^DELETE#/api/v1/users/([^/]+)$",
-		"POST#/api/v1/users":           "8143a9->^POST#/api/v1/users$",
-		"PATCH#/api/v1/users":          "8143a9->^PATCH#/api/v1/users$",
-		"PUT#/api/v1/users":            "8143a9->^PUT#/api/v1/users$",
-	}
+			apiGwCli.
+				On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+					return aws.ToString(in.ResourceId) == tc.expectedResourceID &&
+						aws.ToString(in.PathWithQueryString) == tc.expectedInvokePath
+				})).
+				Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+				Once()
 
-	assert.Equal(t, expectedMappings, mappings)
+			tr := transport.NewTransport(apiGwCli, apiID)
 
-	apiGwCli.AssertExpectations(t)
-}
+			// WHEN
+			match, matchErr := tr.Match(createRequest(tc.method, "https://custom-domain.com", tc.path, http.NoBody))
+			resp, err := tr.RoundTrip(createRequest(tc.method, "https://custom-domain.com", tc.path, http.NoBody))
 
-func TestWithLogger(t *testing.T) {
-	buf := new(bytes.Buffer)
-	log := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			// THEN
+			require.NoError(t, matchErr)
+			assert.Equal(t, tc.expectedParameters, match.PathParameters)
 
-	apiGwCli := new(apiGwClientMock)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-	apiGwCli.
-		On("GetResources", mock.MatchedBy(matchGetResourceInputForAPI("abc123"))).
-		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
-		Once()
+			apiGwCli.AssertExpectations(t)
+		})
+	}
 
-	_, err := transport.NewInitializedTransport(apiGwCli, "abc123", transport.WithLogger(log))
-	require.NoError(t, err)
+	t.Run("encoded slash in a literal segment should not split it", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
 
-	assert.Contains(t, buf.String(), `level=DEBUG msg="initializing endpoint mappings" rest_api_id=abc123`)
-	assert.Contains(t, buf.String(), `level=DEBUG msg="mappings ready" rest_api_id=abc123`)
-}
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: resources}, nil).
+			Once()
 
-type apiGwClientMock struct{ mock.Mock }
+		tr := transport.NewTransport(apiGwCli, apiID)
 
-func (m *apiGwClientMock) TestInvokeMethod(
-	_ context.Context,
-	input *apigateway.TestInvokeMethodInput,
-	_ ...func(*apigateway.Options),
-) (*apigateway.TestInvokeMethodOutput, error) {
-	args := m.Called(input)
+		// WHEN
+		_, err := tr.RoundTrip(createRequest(http.MethodPost, "https://custom-domain.com", "/api%2Fv1/users", http.NoBody))
 
-	var (
-		out *apigateway.TestInvokeMethodOutput
-		err error
-	)
+		// THEN
+		assert.ErrorIs(t, err, transport.ErrResourceNotFound)
+
+		apiGwCli.AssertExpectations(t)
+	})
+}
+
+func TestTransport_RoundTrip_GreedyPathVariable(t *testing.T) {
+	const apiID = "abc123"
 
-	if args.Get(0) != nil {
-		out = args.Get(0).(*apigateway.TestInvokeMethodOutput)
+	resources := []types.Resource{
+		{
+			Id:              aws.String("a1b2c3"),
+			Path:            aws.String("/{proxy+}"),
+			PathPart:        aws.String("{proxy+}"),
+			ResourceMethods: map[string]types.Method{"POST": {}},
+		},
+		{
+			Id:              aws.String("d4e5f6"),
+			Path:            aws.String("/api/v1/files/{proxy+}"),
+			PathPart:        aws.String("{proxy+}"),
+			ResourceMethods: map[string]types.Method{"GET": {}},
+		},
 	}
 
-	if args.Get(1) != nil {
-		err = args.Error(1)
+	testCases := map[string]struct {
+		method             string
+		path               string
+		expectedResourceID string
+	}{
+		"root greedy with nested path": {
+			method:             http.MethodPost,
+			path:               "/any/nested/path",
+			expectedResourceID: "a1b2c3",
+		},
+		"nested greedy with several segments": {
+			method:             http.MethodGet,
+			path:               "/api/v1/files/docs/2024/report.pdf",
+			expectedResourceID: "d4e5f6",
+		},
+		"nested greedy with single segment": {
+			method:             http.MethodGet,
+			path:               "/api/v1/files/report.pdf?download=true",
+			expectedResourceID: "d4e5f6",
+		},
 	}
 
-	return out, err
-}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			httpReq := createRequest(tc.method, "https://custom-domain.com", tc.path, http.NoBody)
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: resources}, nil).
+				Once()
 
-func (m *apiGwClientMock) GetResources(
-	_ context.Context,
-	input *apigateway.GetResourcesInput,
-	_ ...func(*apigateway.Options),
-) (*apigateway.GetResourcesOutput, error) {
-	args := m.Called(input)
+			apiGwCli.
+				On("TestInvokeMethod", mock.MatchedBy(matchTestInvoke(apiID, tc.expectedResourceID, httpReq))).
+				Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+				Once()
 
-	var (
-		out *apigateway.GetResourcesOutput
-		err error
-	)
+			tr := transport.NewTransport(apiGwCli, apiID)
 
-	if args.Get(0) != nil {
-		out = args.Get(0).(*apigateway.GetResourcesOutput)
-	}
+			// WHEN
+			httpResp, err := tr.RoundTrip(httpReq)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, httpResp.StatusCode)
 
-	if args.Get(1) != nil {
-		err = args.Get(1).(error)
+			apiGwCli.AssertExpectations(t)
+		})
 	}
 
-	return out, err
-}
+	t.Run("greedy variable should not match the parent path", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", mock.Anything).
+			Return(&apigateway.GetResourcesOutput{Items: resources}, nil).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID)
 
-func (m *apiGwClientMock) Options() apigateway.Options {
-	return apigateway.Options{Region: "us-east-1"}
+		// WHEN
+		_, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/files/", http.NoBody))
+
+		// THEN
+		assert.ErrorIs(t, err, transport.ErrResourceNotFound)
+	})
 }
 
-func createResources() []types.Resource {
-	return []types.Resource{
-		{
-			Id:   aws.String("b7e20b3a4"),
-			Path: aws.String("/"),
-		},
-		{
-			Id:       aws.String("9b0826"),
-			ParentId: aws.String("b7e20b3a4"),
-			Path:     aws.String("/api"),
-			PathPart: aws.String("api"),
-		},
+func TestTransport_RoundTrip_AnyMethod(t *testing.T) {
+	const apiID = "abc123"
+
+	resources := []types.Resource{
 		{
-			Id:       aws.String("7f4b77"),
-			ParentId: aws.String("9b0826"),
-			Path:     aws.String("/api/v1"),
-			PathPart: aws.String("v1"),
+			Id:              aws.String("a1b2c3"),
+			Path:            aws.String("/{proxy+}"),
+			PathPart:        aws.String("{proxy+}"),
+			ResourceMethods: map[string]types.Method{"ANY": {}},
 		},
 		{
-			Id:              aws.String("8143a9"),
-			ParentId:        aws.String("7f4b77"),
+			Id:              aws.String("d4e5f6"),
 			Path:            aws.String("/api/v1/users"),
 			PathPart:        aws.String("users"),
-			ResourceMethods: map[string]types.Method{"POST": {}, "PUT": {}, "PATCH": {}},
+			ResourceMethods: map[string]types.Method{"ANY": {}, "GET": {}},
 		},
 		{
-			Id:              aws.String("2cb3ff"),
-			ParentId:        aws.String("8143a9"),
-			Path:            aws.String("/api/v1/users/{value}"),
-			PathPart:        aws.String("{value}"),
-			ResourceMethods: map[string]types.Method{"GET": {}, "DELETE": {}},
+			Id:              aws.String("0a9b8c"),
+			Path:            aws.String("/api/v1/status"),
+			PathPart:        aws.String("status"),
+			ResourceMethods: map[string]types.Method{"ANY": {}},
 		},
 	}
-}
 
-func createRequest(method, domain, path string, body io.Reader) *http.Request {
-	httpReq, err := http.NewRequest(method, domain+path, body)
-	if err != nil {
-		panic(err)
+	testCases := map[string]struct {
+		method             string
+		path               string
+		expectedResourceID string
+	}{
+		"GET on greedy ANY": {
+			method:             http.MethodGet,
+			path:               "/any/nested/path",
+			expectedResourceID: "a1b2c3",
+		},
+		"POST on ANY resource": {
+			method:             http.MethodPost,
+			path:               "/api/v1/status",
+			expectedResourceID: "0a9b8c",
+		},
+		"declared method has precedence": {
+			method:             http.MethodGet,
+			path:               "/api/v1/users",
+			expectedResourceID: "d4e5f6",
+		},
+		"ANY used when method is not declared": {
+			method:             http.MethodDelete,
+			path:               "/api/v1/users",
+			expectedResourceID: "d4e5f6",
+		},
 	}
 
-	httpReq.Header.Add("Content-Type", "application/json")
-	httpReq.Header.Add("X-Request-ID", "0123456789")
-	httpReq.Header.Add("X-User-Agent", "test_agent")
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			httpReq := createRequest(tc.method, "https://custom-domain.com", tc.path, http.NoBody)
+			apiGwCli := new(transporttest.Client)
 
-	return httpReq
-}
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: resources}, nil).
+				Once()
+
+			apiGwCli.
+				On("TestInvokeMethod", mock.MatchedBy(matchTestInvoke(apiID, tc.expectedResourceID, httpReq))).
+				Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+				Once()
+
+			tr := transport.NewTransport(apiGwCli, apiID)
+
+			// WHEN
+			httpResp, err := tr.RoundTrip(httpReq)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, httpResp.StatusCode)
 
-func matchGetResourceInputForAPI(apiID string) func(*apigateway.GetResourcesInput) bool {
-	return func(i *apigateway.GetResourcesInput) bool {
-		return *i.RestApiId == apiID
+			apiGwCli.AssertExpectations(t)
+		})
 	}
 }
 
-func matchTestInvoke(apiID, resourceID string, r *http.Request) func(*apigateway.TestInvokeMethodInput) bool {
-	return func(i *apigateway.TestInvokeMethodInput) bool {
-		if i == nil {
-			return false
-		}
+func TestTransport_RoundTrip_PathWithQueryString(t *testing.T) {
+	const apiID = "abc123"
 
-		// match api && resource
-		match := *i.RestApiId == apiID
-		match = match && *i.ResourceId == resourceID
+	testCases := map[string]struct {
+		url      string
+		opts     []transport.Option
+		expected string
+	}{
+		"fragment is dropped": {
+			url:      "https://custom-domain.com/api/v1/users/john.doe#section",
+			expected: "/api/v1/users/john.doe",
+		},
+		"fragment is dropped keeping query": {
+			url:      "https://custom-domain.com/api/v1/users/john.doe?attributes=age#section",
+			expected: "/api/v1/users/john.doe?attributes=age",
+		},
+		"empty query is dropped": {
+			url:      "https://custom-domain.com/api/v1/users/john.doe?",
+			expected: "/api/v1/users/john.doe",
+		},
+		"empty query with fragment is dropped": {
+			url:      "https://custom-domain.com/api/v1/users/john.doe?#section",
+			expected: "/api/v1/users/john.doe",
+		},
+		"empty query is preserved with option": {
+			url:      "https://custom-domain.com/api/v1/users/john.doe?#section",
+			opts:     []transport.Option{transport.WithEmptyQueryPreserved()},
+			expected: "/api/v1/users/john.doe?",
+		},
+		"query without values is forwarded": {
+			url:      "https://custom-domain.com/api/v1/users/john.doe?&",
+			expected: "/api/v1/users/john.doe?&",
+		},
+		"repeated parameters keep their order": {
+			url:      "https://custom-domain.com/api/v1/users/john.doe?tag=b&id=1&tag=a",
+			expected: "/api/v1/users/john.doe?tag=b&id=1&tag=a",
+		},
+		"percent-encoding is forwarded verbatim": {
+			url:      "https://custom-domain.com/api/v1/users/john.doe?q=a%20b+c&sig=AbC%2Fd%3D%3d&x=%7E",
+			expected: "/api/v1/users/john.doe?q=a%20b+c&sig=AbC%2Fd%3D%3d&x=%7E",
+		},
+	}
 
-		// match method
-		match = match && *i.HttpMethod == r.Method
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			httpReq, err := http.NewRequest(http.MethodGet, tc.url, http.NoBody)
+			require.NoError(t, err)
 
-		// match path
-		pathWithQueryString := r.URL.Path + "?" + r.URL.RawQuery
-		pathWithQueryString = strings.TrimSuffix(pathWithQueryString, "?")
+			apiGwCli := new(transporttest.Client)
 
-		if r.Host == apiID+".execute-api.us-east-1.amazonaws.com" {
-			pathWithQueryString = "/" + strings.Join(strings.Split(pathWithQueryString, "/")[2:], "/")
-		}
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+				Once()
 
-		match = match && *i.PathWithQueryString == pathWithQueryString
+			apiGwCli.
+				On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+					return *i.PathWithQueryString == tc.expected
+				})).
+				Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+				Once()
 
-		// match body
-		if r.Body != nil && r.Body != http.NoBody {
-			body := readString(r.Body)
-			match = match && *i.Body == body
-			r.Body = io.NopCloser(strings.NewReader(body))
-		} else {
-			match = match && i.Body == nil
-		}
+			tr := transport.NewTransport(apiGwCli, apiID, tc.opts...)
 
-		// match headers
-		match = match && len(i.MultiValueHeaders) == len(r.Header)
-		for k, v := range i.MultiValueHeaders {
-			match = match && v[0] == r.Header.Get(k)
-		}
+			// WHEN
+			_, err = tr.RoundTrip(httpReq)
 
-		return match
+			// THEN
+			require.NoError(t, err)
+
+			apiGwCli.AssertExpectations(t)
+		})
 	}
 }
 
-func readString(r io.Reader) string {
-	data, err := io.ReadAll(r)
-	if err != nil {
-		panic(err)
+func TestTransport_ConcurrentUse(t *testing.T) {
+	// GIVEN
+	const (
+		apiID    = "abc123"
+		routines = 16
+	)
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil)
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil)
+
+	tr := transport.NewTransport(apiGwCli, apiID,
+		transport.WithSummaryFile(filepath.Join(t.TempDir(), "summary.json")),
+		transport.WithMappingTTL(time.Nanosecond))
+
+	// WHEN
+	var wg sync.WaitGroup
+
+	for i := range routines {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range 20 {
+				switch i % 4 {
+				case 0:
+					assert.NoError(t, tr.Refresh(context.Background()))
+				case 1:
+					assert.NotNil(t, tr.Mappings())
+				case 2:
+					assert.NoError(t, tr.Close())
+				default:
+					httpResp, err := tr.RoundTrip(
+						createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+					if assert.NoError(t, err) {
+						assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+					}
+				}
+			}
+		}()
 	}
 
-	return string(data)
+	wg.Wait()
+
+	// THEN
+	assert.Len(t, tr.Mappings(), 5)
+	apiGwCli.AssertCalled(t, "GetResources", mock.Anything)
+}
+
+func TestTransport_Mappings(t *testing.T) {
+	// GIVEN
+	const apiID = "ortup5gufx"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	httpTransport, err := transport.NewInitializedTransport(apiGwCli, apiID)
+	require.NoError(t, err, "initialization failed")
+
+	// WHEN
+	mappings := httpTransport.Mappings()
+
+	// THEN
+	expectedMappings := map[string]string{
+		"GET#/api/v1/users/{value}":    "2cb3ff->^GET#/api/v1/users/([^/]+)$",
+		"DELETE#/api/v1/users/{value}": "2cb3ff->^DELETE#/api/v1/users/([^/]+)$",
+		"POST#/api/v1/users":           "8143a9->^POST#/api/v1/users$",
+		"PATCH#/api/v1/users":          "8143a9->^PATCH#/api/v1/users$",
+		"PUT#/api/v1/users":            "8143a9->^PUT#/api/v1/users$",
+	}
+
+	assert.Equal(t, expectedMappings, mappings)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestTransport_Routes(t *testing.T) {
+	// GIVEN
+	const apiID = "ortup5gufx"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	httpTransport, err := transport.NewInitializedTransport(apiGwCli, apiID)
+	require.NoError(t, err, "initialization failed")
+
+	// WHEN
+	routes := httpTransport.Routes()
+
+	// THEN
+	expectedRoutes := []transport.Route{
+		{Method: "PATCH", Template: "/api/v1/users", ResourceID: "8143a9", Pattern: "^PATCH#/api/v1/users$"},
+		{Method: "POST", Template: "/api/v1/users", ResourceID: "8143a9", Pattern: "^POST#/api/v1/users$"},
+		{Method: "PUT", Template: "/api/v1/users", ResourceID: "8143a9", Pattern: "^PUT#/api/v1/users$"},
+		{Method: "DELETE", Template: "/api/v1/users/{value}", ResourceID: "2cb3ff", Pattern: "^DELETE#/api/v1/users/([^/]+)$"},
+		{Method: "GET", Template: "/api/v1/users/{value}", ResourceID: "2cb3ff", Pattern: "^GET#/api/v1/users/([^/]+)$"},
+	}
+
+	assert.Equal(t, expectedRoutes, routes)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	log := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor("abc123")).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	_, err := transport.NewInitializedTransport(apiGwCli, "abc123", transport.WithLogger(log))
+	require.NoError(t, err)
+
+	assert.Contains(t, buf.String(), `level=DEBUG msg="initializing endpoint mappings" rest_api_id=abc123`)
+	assert.Contains(t, buf.String(), `level=DEBUG msg="mappings ready" rest_api_id=abc123`)
+}
+
+func TestWithLogAttrs(t *testing.T) {
+	// GIVEN
+	buf := new(bytes.Buffer)
+	log := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor("abc123")).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, "abc123",
+		transport.WithLogAttrs(slog.String("service", "users"), slog.String("team", "identity")),
+		transport.WithLogger(log))
+
+	// WHEN
+	_, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+	// THEN
+	require.NoError(t, err)
+
+	assert.Contains(t, buf.String(), `msg="initializing endpoint mappings" rest_api_id=abc123 service=users team=identity`)
+	assert.Contains(t, buf.String(), `msg="invoke success" rest_api_id=abc123 service=users team=identity`)
+}
+
+func TestWithAccessLog(t *testing.T) {
+	// GIVEN
+	buf := new(bytes.Buffer)
+	log := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor("abc123")).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(`{"id":1}`), Status: http.StatusCreated, Latency: 42}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, "abc123", transport.WithAccessLog(), transport.WithLogger(log),
+		transport.WithClock(&fakeClock{now: time.Unix(0, 0)}))
+
+	// WHEN
+	_, err := tr.RoundTrip(createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users", strings.NewReader(`{"name":"john"}`)))
+	_, notFoundErr := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/not/found", http.NoBody))
+
+	// THEN
+	require.NoError(t, err)
+	require.Error(t, notFoundErr)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2, "only the access records should be logged at info level")
+
+	assert.Contains(t, lines[0], `level=INFO msg=request rest_api_id=abc123 method=POST path=/api/v1/users route=/api/v1/users `+
+		`resource_id=8143a9 status=201 duration=0s invoke_latency=42ms request_size=15 response_size=8`)
+	assert.Contains(t, lines[1], `level=INFO msg="request failed" rest_api_id=abc123 method=GET path=/not/found duration=0s `+
+		`error="resource not found: GET /not/found"`)
+}
+
+func TestWithLogBodyLimit(t *testing.T) {
+	testCases := map[string]struct {
+		opt              transport.Option
+		expectedLogged   []string
+		expectedUnlogged []string
+	}{
+		"truncated bodies": {
+			opt: transport.WithLogBodyLimit(8),
+			expectedLogged: []string{
+				`api_gw_input.body="{\"name\":... (19 bytes)"`,
+				`api_gw_output.body="{\"id\":\"4... (25 bytes)"`,
+			},
+			expectedUnlogged: []string{`john.doe\"}"`},
+		},
+		"without bodies": {
+			opt:              transport.WithoutLogBodies(),
+			expectedLogged:   []string{`api_gw_output.status=201`, `api_gw_input.headers`},
+			expectedUnlogged: []string{`.body=`},
+		},
+		"truncated payload log": {
+			opt:              transport.WithPayloadLog(transport.PayloadLogTruncated),
+			expectedLogged:   []string{`api_gw_input.body="{\"name\":\"john.doe\"}"`, `api_gw_input.headers`},
+			expectedUnlogged: []string{`bytes)"`},
+		},
+		"without payloads": {
+			opt:              transport.WithPayloadLog(transport.PayloadLogNone),
+			expectedLogged:   []string{`api_gw_output.status=201`, `api_gw_input.resource_id=8143a9`},
+			expectedUnlogged: []string{`.body=`, `.headers=`, `.multi_headers_value=`},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			buf := new(bytes.Buffer)
+			log := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor("abc123")).
+				Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+				Once()
+
+			apiGwCli.
+				On("TestInvokeMethod", mock.Anything).
+				Return(&apigateway.TestInvokeMethodOutput{
+					Body:   aws.String(`{"id":"42","name":"john"}`),
+					Status: http.StatusCreated,
+				}, nil).
+				Once()
+
+			tr := transport.NewTransport(apiGwCli, "abc123", transport.WithLogger(log), tc.opt)
+
+			// WHEN
+			_, err := tr.RoundTrip(createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users",
+				strings.NewReader(`{"name":"john.doe"}`)))
+
+			// THEN
+			require.NoError(t, err)
+
+			for _, logged := range tc.expectedLogged {
+				assert.Contains(t, buf.String(), logged)
+			}
+
+			for _, unlogged := range tc.expectedUnlogged {
+				assert.NotContains(t, buf.String(), unlogged)
+			}
+		})
+	}
+}
+
+func TestWithMappingsDebugLog(t *testing.T) {
+	testCases := map[string]struct {
+		opts     []transport.Option
+		expected int
+	}{
+		"mappings not logged by default": {},
+		"mappings logged on every request": {
+			opts:     []transport.Option{transport.WithMappingsDebugLog()},
+			expected: 2,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			buf := new(bytes.Buffer)
+			log := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor("abc123")).
+				Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+				Once()
+
+			apiGwCli.
+				On("TestInvokeMethod", mock.Anything).
+				Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+				Twice()
+
+			tr := transport.NewTransport(apiGwCli, "abc123", append(tc.opts, transport.WithLogger(log))...)
+
+			// WHEN
+			for range 2 {
+				_, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+				require.NoError(t, err)
+			}
+
+			// THEN
+			assert.Equal(t, tc.expected, strings.Count(buf.String(), `msg="resources mapped"`))
+
+			if tc.expected > 0 {
+				assert.Contains(t, buf.String(), `resources.GET#/api/v1/users/{value}.resource_id=2cb3ff`)
+			}
+		})
+	}
+}
+
+func TestWithInitLogger_WithRequestLogger(t *testing.T) {
+	// GIVEN
+	initBuf, reqBuf := new(bytes.Buffer), new(bytes.Buffer)
+	initLog := slog.New(slog.NewTextHandler(initBuf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	reqLog := slog.New(slog.NewTextHandler(reqBuf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor("abc123")).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, "abc123",
+		transport.WithInitLogger(initLog), transport.WithRequestLogger(reqLog))
+
+	// WHEN
+	_, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+	// THEN
+	require.NoError(t, err)
+
+	assert.Contains(t, initBuf.String(), `level=DEBUG msg="initializing endpoint mappings" rest_api_id=abc123`)
+	assert.NotContains(t, initBuf.String(), `msg="invoke success"`)
+
+	assert.Contains(t, reqBuf.String(), `level=DEBUG msg="invoke success" rest_api_id=abc123`)
+	assert.NotContains(t, reqBuf.String(), `msg="initializing endpoint mappings"`)
+}
+
+func TestWithMaxBodySize(t *testing.T) {
+	const apiID = "abc123"
+
+	t.Run("body over the limit should return body too large", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithMaxBodySize(8))
+
+		// WHEN
+		httpResp, err := tr.RoundTrip(createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users",
+			strings.NewReader(`{"name":"john.doe"}`)))
+
+		// THEN
+		assert.Nil(t, httpResp)
+		assert.ErrorIs(t, err, transport.ErrBodyTooLarge)
+
+		var tooLargeErr *transport.BodyTooLargeError
+		require.ErrorAs(t, err, &tooLargeErr)
+		assert.Equal(t, &transport.BodyTooLargeError{Limit: 8, Size: 19}, tooLargeErr)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, tooLargeErr.StatusCode())
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("body within the limit should be invoked", func(t *testing.T) {
+		// GIVEN
+		httpReq := createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users",
+			strings.NewReader(`{"name":"john.doe"}`))
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.MatchedBy(matchTestInvoke(apiID, "8143a9", httpReq))).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusCreated}, nil).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithMaxBodySize(19))
+
+		// WHEN
+		httpResp, err := tr.RoundTrip(httpReq)
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, httpResp.StatusCode)
+
+		apiGwCli.AssertExpectations(t)
+	})
+}
+
+func TestWithRequestParametersValidation(t *testing.T) {
+	const apiID = "abc123"
+
+	resources := []types.Resource{
+		{
+			Id:   aws.String("5e1a7c"),
+			Path: aws.String("/api/v1/orders/{id}"),
+			ResourceMethods: map[string]types.Method{
+				"GET": {RequestParameters: map[string]bool{
+					"method.request.path.id":            true,
+					"method.request.querystring.fields": true,
+					"method.request.querystring.limit":  false,
+					"method.request.header.X-Tenant-ID": true,
+				}},
+			},
+		},
+	}
+
+	t.Run("missing parameters should return error without invoking", func(t *testing.T) {
+		// GIVEN
+		httpReq := createRequest(http.MethodGet, "https://"+apiID+".execute-api.us-east-1.amazonaws.com",
+			"/dev/api/v1/orders/123?limit=1", http.NoBody)
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", mock.MatchedBy(func(in *apigateway.GetResourcesInput) bool {
+				return aws.ToString(in.RestApiId) == apiID && slices.Equal(in.Embed, []string{"methods"})
+			})).
+			Return(&apigateway.GetResourcesOutput{Items: resources}, nil).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithStage("dev"), transport.WithRequestParametersValidation())
+
+		// WHEN
+		httpResp, err := tr.RoundTrip(httpReq)
+
+		// THEN
+		assert.Zero(t, httpResp)
+		assert.ErrorIs(t, err, transport.ErrMissingRequestParameters)
+
+		var paramsErr *transport.MissingParametersError
+		require.ErrorAs(t, err, &paramsErr)
+		assert.Equal(t, []string{"header.X-Tenant-ID", "querystring.fields"}, paramsErr.Parameters)
+		assert.Equal(t, "/api/v1/orders/123", paramsErr.Path, "path should be the matched one, without the stage")
+		assert.Equal(t, http.StatusBadRequest, paramsErr.StatusCode())
+		assert.EqualError(t, err, "missing required request parameters for GET /api/v1/orders/123: "+
+			"header.X-Tenant-ID, querystring.fields")
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("mapping and stage sources should embed the methods", func(t *testing.T) {
+		testCases := map[string]struct {
+			stage string
+			url   string
+			path  string
+		}{
+			"mapping sources": {
+				url:  "https://custom-domain.com",
+				path: "/api/v1/orders/123",
+			},
+			"stages": {
+				stage: "dev",
+				url:   "https://" + apiID + ".execute-api.us-east-1.amazonaws.com",
+				path:  "/dev/api/v1/orders/123",
+			},
+		}
+
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				// GIVEN
+				apiGwCli := new(transporttest.Client)
+
+				apiGwCli.
+					On("GetResources", mock.MatchedBy(func(in *apigateway.GetResourcesInput) bool {
+						return aws.ToString(in.RestApiId) == apiID && slices.Equal(in.Embed, []string{"methods"})
+					})).
+					Return(&apigateway.GetResourcesOutput{Items: resources}, nil)
+
+				opts := []transport.Option{
+					transport.WithMappingSources(transport.ResourcesSource(apiGwCli, apiID)),
+					transport.WithRequestParametersValidation(),
+				}
+
+				if tc.stage != "" {
+					opts = append(opts, transport.WithStages(tc.stage))
+
+					apiGwCli.
+						On("GetStage", mock.Anything).
+						Return(&apigateway.GetStageOutput{DeploymentId: aws.String("d3v")}, nil).
+						Once()
+
+					apiGwCli.
+						On("GetDeployment", mock.Anything).
+						Return(&apigateway.GetDeploymentOutput{ApiSummary: map[string]map[string]types.MethodSnapshot{
+							"/api/v1/orders/{id}": {"GET": {}},
+						}}, nil).
+						Once()
+				}
+
+				tr := transport.NewTransport(apiGwCli, apiID, opts...)
+
+				// WHEN
+				_, err := tr.RoundTrip(createRequest(http.MethodGet, tc.url, tc.path, http.NoBody))
+
+				// THEN
+				var paramsErr *transport.MissingParametersError
+				require.ErrorAs(t, err, &paramsErr)
+				assert.Equal(t, []string{"header.X-Tenant-ID", "querystring.fields"}, paramsErr.Parameters)
+
+				apiGwCli.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("present parameters should invoke", func(t *testing.T) {
+		// GIVEN
+		httpReq := createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/orders/123?fields=id", http.NoBody)
+		httpReq.Header.Set("X-Tenant-ID", "t1")
+
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: resources}, nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.MatchedBy(matchTestInvoke(apiID, "5e1a7c", httpReq))).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithRequestParametersValidation())
+
+		// WHEN
+		httpResp, err := tr.RoundTrip(httpReq)
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+
+		apiGwCli.AssertExpectations(t)
+	})
+}
+
+func TestWithHeadFallback(t *testing.T) {
+	const apiID = "abc123"
+
+	t.Run("should invoke GET and discard the body", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+				return *i.ResourceId == "2cb3ff" && *i.HttpMethod == http.MethodGet
+			})).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(`{"id":"john.doe"}`), Status: http.StatusOK}, nil).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithHeadFallback())
+
+		// WHEN
+		httpResp, err := tr.RoundTrip(createRequest(http.MethodHead, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.Equal(t, int64(17), httpResp.ContentLength)
+		assert.Empty(t, readString(httpResp.Body))
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("HEAD should not match GET by default", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID)
+
+		// WHEN
+		_, err := tr.RoundTrip(createRequest(http.MethodHead, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+		// THEN
+		assert.ErrorIs(t, err, transport.ErrResourceNotFound)
+	})
+}
+
+func TestWithStubbedResponse(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+			return *i.ResourceId == "8143a9"
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusCreated}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID,
+		transport.WithStubbedResponse("GET#/api/v1/users/{value}", http.StatusServiceUnavailable,
+			`{"message":"dependency unavailable"}`, http.Header{"Content-Type": {"application/json"}}),
+		transport.WithStubbedResponse("ANY#/health", http.StatusOK, "ok", nil))
+
+	// WHEN
+	stubbedResp, stubbedErr := tr.RoundTrip(
+		createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+	var anyStatuses []int
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		resp, err := tr.RoundTrip(createRequest(method, "https://custom-domain.com", "/health", http.NoBody))
+		require.NoError(t, err)
+
+		anyStatuses = append(anyStatuses, resp.StatusCode)
+	}
+
+	invokedResp, invokedErr := tr.RoundTrip(
+		createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users", strings.NewReader(`{}`)))
+
+	// THEN
+	require.NoError(t, stubbedErr)
+	assert.Equal(t, http.StatusServiceUnavailable, stubbedResp.StatusCode)
+	assert.Equal(t, http.Header{"Content-Type": {"application/json"}}, stubbedResp.Header)
+	assert.JSONEq(t, `{"message":"dependency unavailable"}`, readString(stubbedResp.Body))
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK}, anyStatuses) // ANY stubs match every method.
+
+	require.NoError(t, invokedErr)
+	assert.Equal(t, http.StatusCreated, invokedResp.StatusCode)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithFollowRedirects(t *testing.T) {
+	const apiID = "abc123"
+
+	redirectOutput := func(location string) *apigateway.TestInvokeMethodOutput {
+		return &apigateway.TestInvokeMethodOutput{
+			Body:              aws.String(""),
+			MultiValueHeaders: map[string][]string{"Location": {location}},
+			Status:            http.StatusFound,
+		}
+	}
+
+	t.Run("should follow redirect to known route", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+				return *i.HttpMethod == http.MethodPost && i.Body != nil
+			})).
+			Return(redirectOutput("https://"+apiID+".execute-api.us-east-1.amazonaws.com/stage/api/v1/users/john.doe"), nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+				return *i.HttpMethod == http.MethodGet && *i.PathWithQueryString == "/api/v1/users/john.doe" && i.Body == nil
+			})).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(`{"username":"john.doe"}`), Status: http.StatusOK}, nil).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithFollowRedirects(5))
+
+		// WHEN
+		httpResp, err := tr.RoundTrip(
+			createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users", strings.NewReader(`{}`)))
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.JSONEq(t, `{"username":"john.doe"}`, readString(httpResp.Body))
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("should return redirect response when location is unknown", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.Anything).
+			Return(redirectOutput("https://other.com/login"), nil).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithFollowRedirects(5))
+
+		// WHEN
+		httpResp, err := tr.RoundTrip(
+			createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusFound, httpResp.StatusCode)
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("should follow redirect routed as round trips are", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+				return *i.HttpMethod == http.MethodGet && *i.PathWithQueryString == "/api/v1/users/john.doe"
+			})).
+			Return(redirectOutput("/api/v1/users/jane.doe/"), nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+				return *i.HttpMethod == http.MethodGet && *i.PathWithQueryString == "/api/v1/users/jane.doe"
+			})).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithFollowRedirects(5),
+			transport.WithHeadFallback(), transport.WithTrailingSlashEquivalence())
+
+		// WHEN
+		httpResp, err := tr.RoundTrip(
+			createRequest(http.MethodHead, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("should follow redirect to a host of the registry", func(t *testing.T) {
+		// GIVEN
+		const ordersAPI = "0rd3r5ap1"
+
+		usersCli, ordersCli := new(transporttest.Client), new(transporttest.Client)
+
+		usersCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		usersCli.
+			On("TestInvokeMethod", transporttest.InvokeOf(apiID, "2cb3ff")).
+			Return(redirectOutput("https://orders.example.com/api/v1/users/john.doe"), nil).
+			Once()
+
+		ordersCli.
+			On("GetResources", transporttest.GetResourcesFor(ordersAPI)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		ordersCli.
+			On("TestInvokeMethod", transporttest.InvokeOf(ordersAPI, "2cb3ff")).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(ordersAPI), Status: http.StatusOK}, nil).
+			Once()
+
+		registry := transport.NewRegistry()
+		registry.Register(transport.NewTransport(usersCli, apiID, transport.WithFollowRedirects(5)), "users.example.com")
+		registry.Register(transport.NewTransport(ordersCli, ordersAPI), "orders.example.com")
+
+		// WHEN
+		httpResp, err := registry.RoundTrip(
+			createRequest(http.MethodGet, "https://users.example.com", "/api/v1/users/john.doe", http.NoBody))
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.Equal(t, ordersAPI, readString(httpResp.Body))
+
+		usersCli.AssertExpectations(t)
+		ordersCli.AssertExpectations(t)
+	})
+
+	t.Run("should return error when exceeding redirects", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.Anything).
+			Return(redirectOutput("/api/v1/users/john.doe"), nil).
+			Times(3)
+
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithFollowRedirects(2))
+
+		// WHEN
+		httpResp, err := tr.RoundTrip(
+			createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+		// THEN
+		assert.Zero(t, httpResp)
+		assert.ErrorIs(t, err, transport.ErrTooManyRedirects)
+
+		apiGwCli.AssertExpectations(t)
+	})
+}
+
+func TestWithMappingSources(t *testing.T) {
+	const apiID = "abc123"
+
+	pinned := transport.StaticSource(
+		transport.Route{Method: http.MethodGet, Template: "/api/v1/users/{value}", ResourceID: "pinned"},
+		transport.Route{Method: http.MethodGet, Template: "/api/v1/health", ResourceID: "h3a1th"},
+	)
+
+	t.Run("higher precedence source should win", func(t *testing.T) {
+		// GIVEN
+		buf := new(bytes.Buffer)
+		log := slog.New(slog.NewTextHandler(buf, nil))
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		// WHEN
+		tr, err := transport.NewInitializedTransport(apiGwCli, apiID,
+			transport.WithLogger(log),
+			transport.WithMappingSources(pinned, transport.ResourcesSource(apiGwCli, apiID)))
+
+		// THEN
+		require.NoError(t, err)
+
+		mappings := tr.Mappings()
+		assert.Len(t, mappings, 6)
+		assert.Equal(t, "pinned->^GET#/api/v1/users/([^/]+)$", mappings["GET#/api/v1/users/{value}"])
+		assert.Equal(t, "h3a1th->^GET#/api/v1/health$", mappings["GET#/api/v1/health"])
+		assert.Equal(t, "2cb3ff->^DELETE#/api/v1/users/([^/]+)$", mappings["DELETE#/api/v1/users/{value}"])
+
+		assert.Contains(t, buf.String(), `level=WARN msg="mapping conflict" rest_api_id=abc123 key=GET#/api/v1/users/{value} `+
+			`kept_source=static kept_resource_id=pinned ignored_source=resources ignored_resource_id=2cb3ff`)
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("strict build should fail on conflicts", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		// WHEN
+		tr, err := transport.NewInitializedTransport(apiGwCli, apiID,
+			transport.WithStrictMappingSources(),
+			transport.WithMappingSources(pinned, transport.ResourcesSource(apiGwCli, apiID)))
+
+		// THEN
+		assert.Zero(t, tr)
+		assert.ErrorIs(t, err, transport.ErrMappingConflict)
+		assert.EqualError(t, err, "mapping conflict: GET#/api/v1/users/{value} is pinned in static source "+
+			"but 2cb3ff in resources source")
+
+		apiGwCli.AssertExpectations(t)
+	})
+}
+
+func TestExportSource(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetExport", mock.MatchedBy(func(i *apigateway.GetExportInput) bool {
+			return *i.RestApiId == apiID && *i.StageName == "prod" && *i.ExportType == "oas30"
+		})).
+		Return(&apigateway.GetExportOutput{Body: []byte(`{
+			"openapi": "3.0.1",
+			"paths": {
+				"/api/v1/users": {
+					"post": {"parameters": [{"name": "X-Tenant-ID", "in": "header", "required": true}]}
+				},
+				"/api/v1/users/{value}": {
+					"get": {"parameters": [{"name": "value", "in": "path", "required": true}]},
+					"x-amazon-apigateway-any-method": {},
+					"parameters": [{"name": "value", "in": "path"}]
+				},
+				"/api/v1/deleted": {"get": {}}
+			}
+		}`)}, nil).
+		Once()
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	// WHEN
+	routes, err := transport.ExportSource(apiGwCli, apiID, "prod").Routes(context.Background())
+
+	// THEN
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []transport.Route{
+		{Method: http.MethodPost, Template: "/api/v1/users", ResourceID: "8143a9", RequiredParameters: []string{"header.X-Tenant-ID"}},
+		{Method: http.MethodGet, Template: "/api/v1/users/{value}", ResourceID: "2cb3ff", RequiredParameters: []string{"path.value"}},
+		{Method: "ANY", Template: "/api/v1/users/{value}", ResourceID: "2cb3ff"},
+	}, routes)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestTransport_ExportOpenAPI(t *testing.T) {
+	// GIVEN
+	tr := transport.NewTransport(new(transporttest.Client), "abc123", transport.WithMappingSources(transport.StaticSource(
+		transport.Route{Method: http.MethodGet, Template: "/api/v1/users/{value}", ResourceID: "2cb3ff"},
+		transport.Route{Method: http.MethodPost, Template: "/api/v1/users", ResourceID: "8143a9",
+			RequiredParameters: []string{"header.X-Tenant-ID"}},
+		transport.Route{Method: "ANY", Template: "/api/v1/files/{proxy+}", ResourceID: "f1l35"},
+	)))
+
+	buf := new(bytes.Buffer)
+
+	// WHEN
+	err := tr.ExportOpenAPI(buf)
+
+	// THEN
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"openapi": "3.0.1",
+		"info": {"title": "abc123", "version": "1.0"},
+		"paths": {
+			"/api/v1/users": {
+				"post": {
+					"parameters": [{"name": "X-Tenant-ID", "in": "header", "required": true, "schema": {"type": "string"}}],
+					"responses": {"default": {"description": "Integration response"}}
+				}
+			},
+			"/api/v1/users/{value}": {
+				"get": {
+					"parameters": [{"name": "value", "in": "path", "required": true, "schema": {"type": "string"}}],
+					"responses": {"default": {"description": "Integration response"}}
+				}
+			},
+			"/api/v1/files/{proxy+}": {
+				"x-amazon-apigateway-any-method": {
+					"parameters": [{"name": "proxy", "in": "path", "required": true, "schema": {"type": "string"}}],
+					"responses": {"default": {"description": "Integration response"}}
+				}
+			}
+		}
+	}`, buf.String())
+}
+
+func TestNewInitializedTransportContext(t *testing.T) {
+	const apiID = "abc123"
+
+	t.Run("should bound the initialization with the context", func(t *testing.T) {
+		// GIVEN
+		ctx, cancel := context.WithCancel(context.Background())
+		release := make(chan struct{})
+
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Run(func(mock.Arguments) { cancel(); <-release }).
+			Return(nil, context.Canceled).
+			Once()
+
+		// WHEN
+		tr, err := transport.NewInitializedTransportContext(ctx, apiGwCli, apiID)
+		close(release)
+
+		// THEN
+		assert.Nil(t, tr)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("should retry an initialization canceled by a request", func(t *testing.T) {
+		// GIVEN
+		ctx, cancel := context.WithCancel(context.Background())
+		release := make(chan struct{})
+
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Run(func(mock.Arguments) { cancel(); <-release }).
+			Return(nil, context.Canceled).
+			Once()
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.Anything).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID)
+		httpReq := createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody)
+
+		// WHEN
+		_, canceledErr := tr.RoundTrip(httpReq.WithContext(ctx))
+		close(release)
+
+		httpResp, err := tr.RoundTrip(httpReq)
+
+		// THEN
+		assert.ErrorIs(t, canceledErr, context.Canceled)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("concurrent first requests should share the initialization", func(t *testing.T) {
+		// GIVEN
+		const requests = 10
+
+		release := make(chan struct{})
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Run(func(mock.Arguments) { <-release }).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.Anything).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+			Times(requests)
+
+		tr := transport.NewTransport(apiGwCli, apiID)
+		errs := make(chan error, requests)
+
+		// WHEN
+		for range requests {
+			go func() {
+				_, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+				errs <- err
+			}()
+		}
+
+		close(release)
+
+		// THEN
+		for range requests {
+			assert.NoError(t, <-errs)
+		}
+
+		apiGwCli.AssertExpectations(t)
+	})
+}
+
+func TestRoundTripMatchPrecedence(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil)
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithMappingSources(transport.StaticSource(
+		transport.Route{Method: http.MethodGet, Template: "/{proxy+}", ResourceID: "root-proxy"},
+		transport.Route{Method: http.MethodGet, Template: "/users/{proxy+}", ResourceID: "users-proxy"},
+		transport.Route{Method: http.MethodGet, Template: "/users/{id}", ResourceID: "user"},
+		transport.Route{Method: http.MethodGet, Template: "/users/{id}/{proxy+}", ResourceID: "user-proxy"},
+		transport.Route{Method: http.MethodGet, Template: "/users/me", ResourceID: "me"},
+		transport.Route{Method: "ANY", Template: "/users/me", ResourceID: "me-any"},
+		transport.Route{Method: "ANY", Template: "/orders/{id}", ResourceID: "order-any"},
+	)))
+
+	tests := map[string]string{
+		"/users/me":          "me",
+		"/users/42":          "user",
+		"/users/42/orders/1": "user-proxy",
+		"/users/me/orders":   "user-proxy",
+		"/other/path":        "root-proxy",
+		"/orders/1":          "order-any",
+	}
+
+	for path, expectedID := range tests {
+		t.Run(path+" should match "+expectedID, func(t *testing.T) {
+			for range 20 {
+				// WHEN
+				httpResp, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", path, http.NoBody))
+
+				// THEN
+				require.NoError(t, err)
+
+				route, _ := transport.RouteFromContext(httpResp.Request.Context())
+				assert.Equal(t, expectedID, route.ResourceID)
+			}
+		})
+	}
+}
+
+func TestNewTransportFromConfig(t *testing.T) {
+	// GIVEN
+	cfg := aws.Config{Region: "eu-west-1", Credentials: aws.AnonymousCredentials{}}
+
+	tr := transport.NewTransportFromConfig(cfg, "abc123",
+		transport.WithStaticMappings(map[string]string{"GET#/api/v1/users/{value}": "2cb3ff"}))
+
+	// WHEN
+	match, err := tr.Match(createRequest(http.MethodGet, "https://abc123.execute-api.eu-west-1.amazonaws.com", "/dev/api/v1/users/john.doe", http.NoBody))
+
+	// THEN
+	require.NoError(t, err, "the invoke URL of the config region should be recognized")
+	assert.Equal(t, "2cb3ff", match.Route.ResourceID)
+	assert.Equal(t, "/api/v1/users/john.doe", match.PathWithQueryString)
+}
+
+func TestNewTransportByAPIName(t *testing.T) {
+	newClient := func() *transporttest.Client {
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetRestApis", mock.MatchedBy(func(i *apigateway.GetRestApisInput) bool { return i.Position == nil })).
+			Return(&apigateway.GetRestApisOutput{
+				Items: []types.RestApi{
+					{Id: aws.String("0rd3r5"), Name: aws.String("orders-service")},
+					{Id: aws.String("l3g4cy"), Name: aws.String("legacy")},
+				},
+				Position: aws.String("page2"),
+			}, nil).
+			Once()
+
+		apiGwCli.
+			On("GetRestApis", mock.MatchedBy(func(i *apigateway.GetRestApisInput) bool { return aws.ToString(i.Position) == "page2" })).
+			Return(&apigateway.GetRestApisOutput{
+				Items: []types.RestApi{
+					{Id: aws.String("us3r5"), Name: aws.String("users-service")},
+					{Id: aws.String("l3g4cy2"), Name: aws.String("legacy")},
+				},
+			}, nil).
+			Once()
+
+		return apiGwCli
+	}
+
+	t.Run("should resolve the API ID of every page", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := newClient()
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor("us3r5")).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		// WHEN
+		tr, err := transport.NewTransportByAPIName(apiGwCli, "users-service")
+
+		// THEN
+		require.NoError(t, err)
+
+		match, err := tr.Match(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+		require.NoError(t, err)
+		assert.Equal(t, "us3r5", match.APIID)
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	testCases := map[string]struct {
+		name        string
+		expectedErr error
+	}{
+		"unknown name should return error":   {name: "payments-service", expectedErr: transport.ErrAPINotFound},
+		"ambiguous name should return error": {name: "legacy", expectedErr: transport.ErrAmbiguousAPIName},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// WHEN
+			tr, err := transport.NewTransportByAPIName(newClient(), tc.name)
+
+			// THEN
+			assert.Zero(t, tr)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	const apiID = "abc123"
+
+	t.Run("should return the match without invoking", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID)
+
+		// WHEN
+		match, err := tr.Match(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe?fields=name", http.NoBody))
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, transport.MatchResult{
+			APIID:               apiID,
+			Route:               transport.Route{Method: http.MethodGet, Template: "/api/v1/users/{value}", ResourceID: "2cb3ff"},
+			PathWithQueryString: "/api/v1/users/john.doe?fields=name",
+			PathParameters:      map[string]string{"value": "john.doe"},
+		}, match)
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("should return resource not found", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID)
+
+		// WHEN
+		match, err := tr.Match(createRequest(http.MethodGet, "https://custom-domain.com", "/not/found", http.NoBody))
+
+		// THEN
+		assert.Zero(t, match)
+		assert.ErrorIs(t, err, transport.ErrResourceNotFound)
+
+		apiGwCli.AssertExpectations(t)
+	})
+}
+
+func TestTransport_MatchResource(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID)
+	_, matchedBeforeInit := tr.MatchResource(http.MethodGet, "/api/v1/users/john.doe")
+
+	require.NoError(t, <-tr.WarmUp(context.Background()))
+
+	// WHEN
+	route, matched := tr.MatchResource(http.MethodGet, "/api/v1/users/john.doe")
+	_, matchedMissing := tr.MatchResource(http.MethodGet, "/api/v1/users")
+
+	// THEN
+	assert.False(t, matchedBeforeInit)
+	assert.True(t, matched)
+	assert.Equal(t, transport.Route{Method: http.MethodGet, Template: "/api/v1/users/{value}", ResourceID: "2cb3ff"}, route)
+	assert.False(t, matchedMissing)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestTransport_BuildPath(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	resources := append(createResources(), types.Resource{
+		Id:              aws.String("d4e5f6"),
+		Path:            aws.String("/api/v1/files/{proxy+}"),
+		PathPart:        aws.String("{proxy+}"),
+		ResourceMethods: map[string]types.Method{"GET": {}},
+	})
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: resources}, nil).
+		Once()
+
+	tr, err := transport.NewInitializedTransport(apiGwCli, apiID)
+	require.NoError(t, err)
+
+	// WHEN
+	userPath, userErr := tr.BuildPath(http.MethodGet, "/api/v1/users/{value}", map[string]string{"value": "john doe"})
+	filePath, fileErr := tr.BuildPath(http.MethodGet, "/api/v1/files/{proxy+}", map[string]string{"proxy": "docs/a b.txt"})
+	literalPath, literalErr := tr.BuildPath(http.MethodPost, "/api/v1/users", nil)
+	_, missingErr := tr.BuildPath(http.MethodGet, "/api/v1/users/{value}", map[string]string{"id": "john"})
+	_, notMappedErr := tr.BuildPath(http.MethodPost, "/api/v1/users/{value}", map[string]string{"value": "john"})
+
+	// THEN
+	require.NoError(t, userErr)
+	assert.Equal(t, "/api/v1/users/john%20doe", userPath)
+
+	require.NoError(t, fileErr)
+	assert.Equal(t, "/api/v1/files/docs/a%20b.txt", filePath)
+
+	require.NoError(t, literalErr)
+	assert.Equal(t, "/api/v1/users", literalPath)
+
+	assert.ErrorIs(t, missingErr, transport.ErrMissingPathParameters)
+	assert.ErrorContains(t, missingErr, "value")
+	assert.ErrorIs(t, notMappedErr, transport.ErrResourceNotFound)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestRouteFromContext(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	expectedRoute := transport.Route{Method: http.MethodGet, Template: "/api/v1/users/{value}", ResourceID: "2cb3ff"}
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID)
+
+	// WHEN
+	httpResp, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+	// THEN
+	require.NoError(t, err)
+
+	respRoute, found := transport.RouteFromContext(httpResp.Request.Context())
+	assert.True(t, found)
+	assert.Equal(t, expectedRoute, respRoute)
+
+	invokeRoute, found := transport.RouteFromContext(lastInvocation(t, apiGwCli).Context)
+	assert.True(t, found)
+	assert.Equal(t, expectedRoute, invokeRoute)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestNewInvokeContext(t *testing.T) {
+	const apiID = "abc123"
+
+	t.Run("should apply the overrides to the invoke", func(t *testing.T) {
+		// GIVEN
+		const otherAPIID = "def456"
+
+		creds := aws.AnonymousCredentials{}
+
+		httpReq := createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody)
+		httpReq.Header.Set("X-Original", "kept")
+		httpReq.Header.Set("X-Tenant", "original")
+
+		httpReq = httpReq.WithContext(transport.NewInvokeContext(httpReq.Context(), transport.InvokeContext{
+			APIID:          otherAPIID,
+			Stage:          "beta",
+			StageVariables: map[string]string{"env": "test"},
+			Credentials:    creds,
+			Headers:        http.Header{"x-tenant": {"acme"}},
+		}))
+
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{}, nil).
+			Once()
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(otherAPIID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.
+			On("GetStage", &apigateway.GetStageInput{RestApiId: aws.String(otherAPIID), StageName: aws.String("beta")}).
+			Return(&apigateway.GetStageOutput{Variables: map[string]string{"env": "beta", "region": "eu"}}, nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+				return *in.RestApiId == otherAPIID &&
+					assert.ObjectsAreEqual(map[string]string{"env": "test", "region": "eu"}, in.StageVariables) &&
+					assert.ObjectsAreEqual([]string{"acme"}, in.MultiValueHeaders["X-Tenant"]) &&
+					assert.ObjectsAreEqual([]string{"kept"}, in.MultiValueHeaders["X-Original"])
+			})).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+			Twice()
+
+		tr := transport.NewTransport(apiGwCli, apiID)
+
+		// WHEN
+		_, err := tr.RoundTrip(httpReq)
+		require.NoError(t, err)
+
+		_, err = tr.RoundTrip(httpReq)
+		require.NoError(t, err)
+
+		// THEN
+		assert.Equal(t, "original", httpReq.Header.Get("X-Tenant"))
+		assert.Equal(t, creds, lastInvocation(t, apiGwCli).Options.Credentials)
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("should fetch the stages concurrently, once per stage", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		started, release := make(chan struct{}), make(chan struct{})
+
+		apiGwCli.
+			On("GetStage", &apigateway.GetStageInput{RestApiId: aws.String(apiID), StageName: aws.String("slow")}).
+			Run(func(mock.Arguments) {
+				close(started)
+				<-release
+			}).
+			Return(&apigateway.GetStageOutput{Variables: map[string]string{"env": "slow"}}, nil).
+			Once()
+
+		apiGwCli.
+			On("GetStage", &apigateway.GetStageInput{RestApiId: aws.String(apiID), StageName: aws.String("fast")}).
+			Return(&apigateway.GetStageOutput{Variables: map[string]string{"env": "fast"}}, nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.Anything).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+			Times(4)
+
+		tr := transport.NewTransport(apiGwCli, apiID)
+
+		roundTripOn := func(stage string) error {
+			httpReq := createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody)
+			_, err := tr.RoundTrip(httpReq.WithContext(transport.NewInvokeContext(httpReq.Context(), transport.InvokeContext{Stage: stage})))
+
+			return err
+		}
+
+		// WHEN
+		var wg sync.WaitGroup
+
+		slowErrs := make(chan error, 3)
+
+		for range 3 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+				slowErrs <- roundTripOn("slow")
+			}()
+		}
+
+		<-started
+
+		fastDone := make(chan error, 1)
+		go func() { fastDone <- roundTripOn("fast") }()
+
+		// THEN
+		select {
+		case err := <-fastDone:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the fast stage should not wait for the slow one")
+		}
+
+		close(release)
+		wg.Wait()
+		close(slowErrs)
+
+		for err := range slowErrs {
+			require.NoError(t, err)
+		}
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("should reject an invalid invoke context", func(t *testing.T) {
+		// GIVEN
+		httpReq := createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody)
+		httpReq = httpReq.WithContext(transport.NewInvokeContext(httpReq.Context(), transport.InvokeContext{
+			StageVariables: map[string]string{"not-valid": "value"},
+		}))
+
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID)
+
+		// WHEN
+		httpResp, err := tr.RoundTrip(httpReq)
+
+		// THEN
+		assert.Nil(t, httpResp)
+		assert.ErrorIs(t, err, transport.ErrInvalidInvokeContext)
+		assert.EqualError(t, err, `invalid invoke context: invalid stage variable name "not-valid"`)
+
+		apiGwCli.AssertExpectations(t)
+	})
+}
+
+func TestContextWithResourceID(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", transporttest.InvokeOf(apiID, "8143a9")).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Twice()
+
+	tr := transport.NewTransport(apiGwCli, apiID)
+
+	// WHEN
+	pinnedResp, pinnedErr := tr.RoundTrip(createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody).
+		WithContext(transport.ContextWithResourceID(context.Background(), "8143a9")))
+
+	unmappedResp, unmappedErr := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/legacy/users", http.NoBody).
+		WithContext(transport.ContextWithResourceID(context.Background(), "8143a9")))
+
+	// THEN
+	require.NoError(t, pinnedErr, "the pinned resource should be invoked whatever the path")
+	route, _ := transport.RouteFromContext(pinnedResp.Request.Context())
+	assert.Equal(t, transport.Route{Method: http.MethodPost, Template: "/api/v1/users", ResourceID: "8143a9"}, route)
+
+	require.NoError(t, unmappedErr, "the pinned resource should be invoked whatever the method")
+	route, _ = transport.RouteFromContext(unmappedResp.Request.Context())
+	assert.Equal(t, transport.Route{Method: http.MethodGet, Template: "/legacy/users", ResourceID: "8143a9"}, route)
+
+	assert.Equal(t, "/api/v1/users/john.doe", *apiGwCli.Invocations()[0].Input.PathWithQueryString)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestPathParametersFromContext(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	buf := new(bytes.Buffer)
+	log := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	var intercepted map[string]string
+
+	intercept := func(next transport.Invoker) transport.Invoker {
+		return transport.InvokerFunc(func(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error) {
+			intercepted, _ = transport.PathParametersFromContext(r.Context())
+			return next.Invoke(r, input)
+		})
+	}
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithLogger(log), transport.WithInterceptor(intercept),
+		transport.WithMappingSources(transport.StaticSource(
+			transport.Route{Method: http.MethodGet, Template: "/api/v1/users/{user}/files/{path+}", ResourceID: "f1l35"},
+		)))
+
+	// WHEN
+	httpResp, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe/files/a/b.txt", http.NoBody))
+
+	// THEN
+	require.NoError(t, err)
+
+	expected := map[string]string{"user": "john.doe", "path": "a/b.txt"}
+	assert.Equal(t, expected, intercepted)
+
+	params, found := transport.PathParametersFromContext(httpResp.Request.Context())
+	assert.True(t, found)
+	assert.Equal(t, expected, params)
+
+	assert.Contains(t, buf.String(), `path_parameters="map[path:a/b.txt user:john.doe]"`)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestInvokeLatency(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK, Latency: 42}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID)
+
+	// WHEN
+	httpResp, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+	// THEN
+	require.NoError(t, err)
+
+	latency, found := transport.InvokeLatency(httpResp)
+	assert.True(t, found)
+	assert.Equal(t, 42*time.Millisecond, latency)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestOutputFromResponse(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	out := &apigateway.TestInvokeMethodOutput{
+		Body:              aws.String(""),
+		Status:            http.StatusOK,
+		Log:               aws.String("Execution log for request 0123456789"),
+		MultiValueHeaders: map[string][]string{"x-trace-id": {"a", "b"}},
+	}
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(out, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithStubbedResponse("GET#/health", http.StatusOK, "ok", nil))
+
+	// WHEN
+	httpResp, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+	stubResp, stubErr := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/health", http.NoBody))
+
+	// THEN
+	require.NoError(t, err)
+	require.NoError(t, stubErr)
+
+	rawOut, found := transport.OutputFromResponse(httpResp)
+	assert.True(t, found)
+	assert.Same(t, out, rawOut)
+
+	_, found = transport.OutputFromResponse(stubResp)
+	assert.False(t, found, "stubbed responses have no output")
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithStaticMappings(t *testing.T) {
+	const apiID = "abc123"
+
+	t.Run("should route without getting resources", func(t *testing.T) {
+		// GIVEN
+		httpReq := createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody)
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.MatchedBy(matchTestInvoke(apiID, "2cb3ff", httpReq))).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithStaticMappings(map[string]string{
+			"GET#/api/v1/users/{value}": "2cb3ff",
+			"POST#/api/v1/users":        "8143a9",
+		}))
+
+		// WHEN
+		httpResp, err := tr.RoundTrip(httpReq)
+
+		// THEN
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+		assert.Len(t, tr.Mappings(), 2)
+
+		apiGwCli.AssertExpectations(t)
+		apiGwCli.AssertNotCalled(t, "GetResources", mock.Anything)
+	})
+
+	t.Run("invalid key should return error", func(t *testing.T) {
+		// WHEN
+		tr, err := transport.NewInitializedTransport(new(transporttest.Client), apiID,
+			transport.WithStaticMappings(map[string]string{"/api/v1/users": "8143a9"}))
+
+		// THEN
+		assert.Zero(t, tr)
+		assert.ErrorIs(t, err, transport.ErrInvalidMappingKey)
+	})
+}
+
+func TestWithGetResourcesInput(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+	resources := createResources()
+
+	matchPage := func(position *string) func(*apigateway.GetResourcesInput) bool {
+		return func(i *apigateway.GetResourcesInput) bool {
+			return *i.RestApiId == apiID && slices.Equal(i.Embed, []string{"methods"}) &&
+				aws.ToInt32(i.Limit) == 500 && aws.ToString(i.Position) == aws.ToString(position)
+		}
+	}
+
+	apiGwCli.
+		On("GetResources", mock.MatchedBy(matchPage(nil))).
+		Return(&apigateway.GetResourcesOutput{Items: resources[:4], Position: aws.String("page2")}, nil).
+		Once()
+
+	apiGwCli.
+		On("GetResources", mock.MatchedBy(matchPage(aws.String("page2")))).
+		Return(&apigateway.GetResourcesOutput{Items: resources[4:]}, nil).
+		Once()
+
+	// WHEN
+	tr, err := transport.NewInitializedTransport(apiGwCli, apiID,
+		transport.WithGetResourcesInput(func(i *apigateway.GetResourcesInput) {
+			i.Embed = []string{"methods"}
+			i.Limit = aws.Int32(500)
+		}))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Len(t, tr.Mappings(), 5, "routes of every page should be mapped")
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithStageVariables(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	stageVars := map[string]string{"lambdaAlias": "dev", "featureFlag": "on"}
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+			return assert.ObjectsAreEqual(stageVars, i.StageVariables)
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Twice()
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithStageVariables(stageVars))
+
+	// WHEN
+	for _, path := range []string{"/api/v1/users/john.doe", "/api/v1/users/jane.doe"} {
+		_, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", path, http.NoBody))
+		require.NoError(t, err)
+	}
+
+	// THEN
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithClientCertificateID(t *testing.T) {
+	const apiID = "abc123"
+
+	testCases := map[string]struct {
+		opts        []transport.Option
+		stageOutput *apigateway.GetStageOutput
+	}{
+		"explicit certificate": {
+			opts: []transport.Option{transport.WithClientCertificateID("cert01")},
+		},
+		"certificate discovered from stage": {
+			opts:        []transport.Option{transport.WithClientCertificateFromStage("dev")},
+			stageOutput: &apigateway.GetStageOutput{ClientCertificateId: aws.String("cert01")},
+		},
+		"explicit certificate has precedence": {
+			opts: []transport.Option{
+				transport.WithClientCertificateFromStage("dev"),
+				transport.WithClientCertificateID("cert01"),
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+				Once()
+
+			if tc.stageOutput != nil {
+				apiGwCli.
+					On("GetStage", mock.MatchedBy(func(i *apigateway.GetStageInput) bool {
+						return *i.RestApiId == apiID && *i.StageName == "dev"
+					})).
+					Return(tc.stageOutput, nil).
+					Once()
+			}
+
+			apiGwCli.
+				On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+					return aws.ToString(i.ClientCertificateId) == "cert01"
+				})).
+				Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+				Once()
+
+			tr := transport.NewTransport(apiGwCli, apiID, tc.opts...)
+
+			// WHEN
+			_, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+			// THEN
+			require.NoError(t, err)
+
+			apiGwCli.AssertExpectations(t)
+		})
+	}
+
+	t.Run("get stage error should return error", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", mock.Anything).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.
+			On("GetStage", mock.Anything).
+			Return(nil, errors.New("something went wrong")).
+			Once()
+
+		// WHEN
+		tr, err := transport.NewInitializedTransport(apiGwCli, apiID, transport.WithClientCertificateFromStage("dev"))
+
+		// THEN
+		assert.Zero(t, tr)
+		assert.EqualError(t, err, "get stage error: something went wrong")
+
+		apiGwCli.AssertExpectations(t)
+	})
+}
+
+func TestTransport_RoundTrip_BinaryBody(t *testing.T) {
+	const apiID = "abc123"
+
+	pngBytes := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a}
+
+	testCases := map[string]struct {
+		contentType  string
+		body         []byte
+		opts         []transport.Option
+		expectedBody string
+	}{
+		"text body is sent raw": {
+			contentType:  "application/json",
+			body:         []byte(`{"username":"john.doe"}`),
+			expectedBody: `{"username":"john.doe"}`,
+		},
+		"binary content type is base64 encoded": {
+			contentType:  "image/png",
+			body:         pngBytes,
+			expectedBody: base64.StdEncoding.EncodeToString(pngBytes),
+		},
+		"invalid utf-8 body is base64 encoded": {
+			contentType:  "application/json",
+			body:         pngBytes,
+			expectedBody: base64.StdEncoding.EncodeToString(pngBytes),
+		},
+		"forced binary content type is base64 encoded": {
+			contentType:  "application/vnd.custom+json; charset=utf-8",
+			body:         []byte(`{"username":"john.doe"}`),
+			opts:         []transport.Option{transport.WithBinaryMediaTypes("application/vnd.custom+json")},
+			expectedBody: base64.StdEncoding.EncodeToString([]byte(`{"username":"john.doe"}`)),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			httpReq := createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users", bytes.NewReader(tc.body))
+			httpReq.Header.Set("Content-Type", tc.contentType)
+
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+				Once()
+
+			apiGwCli.
+				On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+					return aws.ToString(i.Body) == tc.expectedBody
+				})).
+				Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusCreated}, nil).
+				Once()
+
+			tr := transport.NewTransport(apiGwCli, apiID, tc.opts...)
+
+			// WHEN
+			_, err := tr.RoundTrip(httpReq)
+
+			// THEN
+			require.NoError(t, err)
+
+			apiGwCli.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	metrics := &metricsRecorder{}
+	clock := &fakeClock{now: time.Now()}
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithMetrics(metrics), transport.WithClock(clock))
+
+	// WHEN
+	_, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+	require.NoError(t, err)
+
+	_, err = tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/not/found", http.NoBody))
+	require.ErrorIs(t, err, transport.ErrResourceNotFound)
+
+	// THEN
+	assert.Equal(t, []string{"GET#/api/v1/users/{value} 200"}, metrics.invoked)
+	assert.Equal(t, []string{"resource_not_found"}, metrics.failed)
+	assert.Equal(t, []string{http.MethodGet}, metrics.missed)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithInvokeTimeout(t *testing.T) {
+	const apiID = "abc123"
+
+	tests := map[string]error{
+		"deadline exceeded": context.DeadlineExceeded,
+		"gateway timeout": &awshttp.ResponseError{ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusGatewayTimeout}},
+			Err:      errors.New("endpoint request timed out"),
+		}},
+	}
+
+	for name, invokeErr := range tests {
+		t.Run(name+" should return integration timeout", func(t *testing.T) {
+			// GIVEN
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+				Once()
+
+			apiGwCli.
+				On("TestInvokeMethod", mock.Anything).
+				Return(nil, invokeErr).
+				Once()
+
+			tr := transport.NewTransport(apiGwCli, apiID, transport.WithInvokeTimeout(time.Minute))
+
+			// WHEN
+			_, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+			// THEN
+			assert.ErrorIs(t, err, transport.ErrIntegrationTimeout)
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+			deadline, hasDeadline := (lastInvocation(t, apiGwCli).Context).Deadline()
+			assert.True(t, hasDeadline)
+			assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+
+			apiGwCli.AssertExpectations(t)
+		})
+	}
+
+	t.Run("other errors should not return integration timeout", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.Anything).
+			Return(nil, errors.New("something went wrong")).
+			Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithInvokeTimeout(time.Minute))
+
+		// WHEN
+		_, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+		// THEN
+		assert.NotErrorIs(t, err, transport.ErrIntegrationTimeout)
+		assert.NotErrorIs(t, err, context.DeadlineExceeded)
+
+		apiGwCli.AssertExpectations(t)
+	})
+}
+
+func TestWithRetry(t *testing.T) {
+	const apiID = "abc123"
+
+	throttled := &awshttp.ResponseError{ResponseError: &smithyhttp.ResponseError{
+		Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusTooManyRequests}},
+		Err:      errors.New("too many requests"),
+	}}
+
+	testCases := map[string]struct {
+		method           string
+		invokeErrs       []error
+		expectedAttempts int
+		expectedErr      bool
+	}{
+		"idempotent request should be retried until success": {
+			method:           http.MethodPut,
+			invokeErrs:       []error{throttled, throttled},
+			expectedAttempts: 3,
+		},
+		"retries should stop after the max attempts": {
+			method:           http.MethodPut,
+			invokeErrs:       []error{throttled, throttled, throttled},
+			expectedAttempts: 3,
+			expectedErr:      true,
+		},
+		"non idempotent request should not be retried": {
+			method:           http.MethodPost,
+			invokeErrs:       []error{throttled},
+			expectedAttempts: 1,
+			expectedErr:      true,
+		},
+		"permanent failure should not be retried": {
+			method:           http.MethodPut,
+			invokeErrs:       []error{errors.New("access denied")},
+			expectedAttempts: 1,
+			expectedErr:      true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			buf := new(bytes.Buffer)
+			log := slog.New(slog.NewTextHandler(buf, nil))
+			clock := &fakeClock{now: time.Unix(0, 0)}
+
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+				Once()
+
+			for _, err := range tc.invokeErrs {
+				apiGwCli.On("TestInvokeMethod", mock.Anything).Return(nil, err).Once()
+			}
+
+			apiGwCli.
+				On("TestInvokeMethod", mock.Anything).
+				Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+				Maybe()
+
+			var bodies []string
+
+			recordBody := func(next transport.Invoker) transport.Invoker {
+				return transport.InvokerFunc(func(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error) {
+					bodies = append(bodies, readString(r.Body))
+					return next.Invoke(r, input)
+				})
+			}
+
+			tr := transport.NewTransport(apiGwCli, apiID, transport.WithLogger(log), transport.WithClock(clock),
+				transport.WithInterceptor(recordBody), transport.WithRetry(3, 100*time.Millisecond))
+
+			// WHEN
+			_, err := tr.RoundTrip(createRequest(tc.method, "https://custom-domain.com", "/api/v1/users",
+				strings.NewReader(`{"name":"john.doe"}`)))
+
+			// THEN
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Len(t, apiGwCli.Invocations(), tc.expectedAttempts)
+			assert.Len(t, bodies, tc.expectedAttempts)
+
+			for _, body := range bodies {
+				assert.Equal(t, `{"name":"john.doe"}`, body, "the body should be replayed on every attempt")
+			}
+			assert.Equal(t, tc.expectedAttempts-1, strings.Count(buf.String(), `msg="invoke attempt failed"`))
+
+			if tc.expectedAttempts == 3 {
+				assert.Equal(t, 300*time.Millisecond, clock.Now().Sub(time.Unix(0, 0)), "backoff should double")
+			}
+		})
+	}
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", transporttest.InvokeOf(apiID, "2cb3ff")).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusBadGateway}, nil).
+		Times(3)
+
+	apiGwCli.
+		On("TestInvokeMethod", transporttest.InvokeOf(apiID, "2cb3ff")).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Twice()
+
+	apiGwCli.
+		On("TestInvokeMethod", transporttest.InvokeOf(apiID, "8143a9")).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusCreated}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithClock(clock),
+		transport.WithCircuitBreaker(transport.CircuitBreaker{FailureThreshold: 2, OpenTimeout: time.Minute}))
+
+	get := func() (*http.Response, error) {
+		return tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+	}
+
+	// WHEN
+	for range 2 {
+		resp, err := get()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	}
+
+	_, openErr := get()
+	_, otherRouteErr := tr.RoundTrip(createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users", http.NoBody))
+
+	clock.Advance(time.Minute)
+	failedProbe, failedProbeErr := get()
+	_, reopenedErr := get()
+
+	clock.Advance(time.Minute)
+	probe, probeErr := get()
+	closed, closedErr := get()
+
+	// THEN
+	assert.ErrorIs(t, openErr, transport.ErrCircuitOpen)
+	assert.NoError(t, otherRouteErr, "circuits should be per route")
+
+	require.NoError(t, failedProbeErr)
+	assert.Equal(t, http.StatusBadGateway, failedProbe.StatusCode)
+	assert.ErrorIs(t, reopenedErr, transport.ErrCircuitOpen, "a failed probe should open the circuit again")
+
+	require.NoError(t, probeErr)
+	assert.Equal(t, http.StatusOK, probe.StatusCode)
+	require.NoError(t, closedErr, "a successful probe should close the circuit")
+	assert.Equal(t, http.StatusOK, closed.StatusCode)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithResponseCache(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	revalidation := func(i *apigateway.TestInvokeMethodInput) bool {
+		return assert.ObjectsAreEqual([]string{`"v1"`}, i.MultiValueHeaders["If-None-Match"])
+	}
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+			return *i.PathWithQueryString == "/api/v1/users/john.doe" && !revalidation(i)
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{
+			Body:              aws.String(`{"id":"john.doe"}`),
+			Status:            http.StatusOK,
+			MultiValueHeaders: map[string][]string{"Cache-Control": {"max-age=60"}, "Etag": {`"v1"`}},
+		}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(revalidation)).
+		Return(&apigateway.TestInvokeMethodOutput{
+			Body:              aws.String(""),
+			Status:            http.StatusNotModified,
+			MultiValueHeaders: map[string][]string{"Cache-Control": {"max-age=60"}},
+		}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+			return *i.PathWithQueryString == "/api/v1/users/jane.doe"
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{
+			Body:              aws.String(`{"id":"jane.doe"}`),
+			Status:            http.StatusOK,
+			MultiValueHeaders: map[string][]string{"Cache-Control": {"no-store"}},
+		}, nil).
+		Twice()
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithClock(clock), transport.WithResponseCache(nil))
+
+	get := func(path string) string {
+		resp, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", path, http.NoBody))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		return readString(resp.Body)
+	}
+
+	// WHEN
+	bodies := []string{get("/api/v1/users/john.doe"), get("/api/v1/users/john.doe")}
+	invokesFresh := len(apiGwCli.Invocations())
+
+	clock.Advance(61 * time.Second)
+	bodies = append(bodies, get("/api/v1/users/john.doe"), get("/api/v1/users/john.doe"))
+	invokesRevalidated := len(apiGwCli.Invocations())
+
+	get("/api/v1/users/jane.doe")
+	get("/api/v1/users/jane.doe")
+
+	// THEN
+	assert.Equal(t, 1, invokesFresh, "fresh hits should not be invoked")
+	assert.Equal(t, 2, invokesRevalidated, "stale responses should be revalidated once")
+
+	for _, body := range bodies {
+		assert.Equal(t, `{"id":"john.doe"}`, body)
+	}
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithResponseCacheKey(t *testing.T) {
+	const apiID = "abc123"
+
+	cacheable := func(body string) *apigateway.TestInvokeMethodOutput {
+		return &apigateway.TestInvokeMethodOutput{
+			Body:              aws.String(body),
+			Status:            http.StatusOK,
+			MultiValueHeaders: map[string][]string{"Cache-Control": {"max-age=60"}, "Vary": {"Accept-Language"}},
+		}
+	}
+
+	headerOf := func(name, value string) any {
+		return mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+			return assert.ObjectsAreEqual([]string{value}, i.MultiValueHeaders[name])
+		})
+	}
+
+	get := func(t *testing.T, tr http.RoundTripper, r *http.Request) string {
+		t.Helper()
+
+		resp, err := tr.RoundTrip(r)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		return readString(resp.Body)
+	}
+
+	t.Run("should not share responses across stages", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		for _, stage := range []string{"dev", "prod"} {
+			apiGwCli.
+				On("GetStage", &apigateway.GetStageInput{RestApiId: aws.String(apiID), StageName: aws.String(stage)}).
+				Return(&apigateway.GetStageOutput{}, nil).
+				Once()
+		}
+
+		apiGwCli.On("TestInvokeMethod", mock.Anything).Return(cacheable(`{"stage":"dev"}`), nil).Once()
+		apiGwCli.On("TestInvokeMethod", mock.Anything).Return(cacheable(`{"stage":"prod"}`), nil).Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithResponseCache(nil))
+
+		getOn := func(stage string) string {
+			r := createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody)
+			return get(t, tr, r.WithContext(transport.NewInvokeContext(r.Context(), transport.InvokeContext{Stage: stage})))
+		}
+
+		// WHEN
+		bodies := []string{getOn("dev"), getOn("prod"), getOn("dev"), getOn("prod")}
+
+		// THEN
+		assert.Equal(t, []string{`{"stage":"dev"}`, `{"stage":"prod"}`, `{"stage":"dev"}`, `{"stage":"prod"}`}, bodies)
+		apiGwCli.AssertNumberOfCalls(t, "TestInvokeMethod", 2)
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("should not share responses across authorization tokens", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		for _, token := range []string{"alice", "bob"} {
+			apiGwCli.
+				On("TestInvokeMethod", headerOf("Authorization", "Bearer "+token)).
+				Return(cacheable(`{"id":"`+token+`"}`), nil).
+				Once()
+		}
+
+		store := transport.NewMemoryCache(0)
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithResponseCache(store))
+
+		getWith := func(token string) string {
+			r := createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/me", http.NoBody)
+			r.Header.Set("Authorization", "Bearer "+token)
+
+			return get(t, tr, r)
+		}
+
+		// WHEN
+		bodies := []string{getWith("alice"), getWith("bob"), getWith("alice"), getWith("bob")}
+
+		// THEN
+		assert.Equal(t, []string{`{"id":"alice"}`, `{"id":"bob"}`, `{"id":"alice"}`, `{"id":"bob"}`}, bodies)
+
+		_, found := store.Get(apiID + "  /api/v1/users/me")
+		assert.False(t, found, "authorized responses should not be keyed by path only")
+
+		apiGwCli.AssertNumberOfCalls(t, "TestInvokeMethod", 2)
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("should not cache the requests invoking with their credentials", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.On("TestInvokeMethod", mock.Anything).Return(cacheable(`{"id":"tenant-a"}`), nil).Once()
+		apiGwCli.On("TestInvokeMethod", mock.Anything).Return(cacheable(`{"id":"tenant-b"}`), nil).Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithResponseCache(nil))
+
+		getAs := func(accessKeyID string) string {
+			creds := aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{AccessKeyID: accessKeyID}, nil
+			})
+
+			r := createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/me", http.NoBody)
+
+			return get(t, tr, r.WithContext(transport.NewInvokeContext(r.Context(), transport.InvokeContext{Credentials: creds})))
+		}
+
+		// WHEN
+		bodies := []string{getAs("tenant-a"), getAs("tenant-b")}
+
+		// THEN
+		assert.Equal(t, []string{`{"id":"tenant-a"}`, `{"id":"tenant-b"}`}, bodies)
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("cache hits should not report invoke data", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.On("TestInvokeMethod", mock.Anything).Return(cacheable(`{"id":"john.doe"}`), nil).Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithResponseCache(nil))
+
+		// WHEN
+		invoked, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+		require.NoError(t, err)
+
+		hit, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+		require.NoError(t, err)
+
+		// THEN
+		_, invokedHasOutput := transport.OutputFromResponse(invoked)
+		_, hitHasOutput := transport.OutputFromResponse(hit)
+		_, hitHasLatency := transport.InvokeLatency(hit)
+
+		assert.True(t, invokedHasOutput)
+		assert.False(t, hitHasOutput)
+		assert.False(t, hitHasLatency)
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("should honor the Vary header", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.On("TestInvokeMethod", headerOf("Accept-Language", "en")).Return(cacheable(`{"greeting":"hello"}`), nil).Once()
+		apiGwCli.On("TestInvokeMethod", headerOf("Accept-Language", "fr")).Return(cacheable(`{"greeting":"bonjour"}`), nil).Once()
+
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithResponseCache(nil))
+
+		getIn := func(lang string) string {
+			r := createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody)
+			r.Header.Set("Accept-Language", lang)
+
+			return get(t, tr, r)
+		}
+
+		// WHEN
+		bodies := []string{getIn("en"), getIn("en"), getIn("fr")}
+
+		// THEN
+		assert.Equal(t, []string{`{"greeting":"hello"}`, `{"greeting":"hello"}`, `{"greeting":"bonjour"}`}, bodies)
+		apiGwCli.AssertExpectations(t)
+	})
+}
+
+func TestNewMemoryCache(t *testing.T) {
+	// GIVEN
+	store := transport.NewMemoryCache(2)
+	now := time.Now()
+
+	// WHEN
+	store.Set("expired", &transport.CachedResponse{Expires: now.Add(-time.Minute)})
+	store.Set("first", &transport.CachedResponse{Expires: now.Add(time.Hour)})
+	store.Set("second", &transport.CachedResponse{Expires: now.Add(2 * time.Hour)})
+	_, expiredKept := store.Get("expired")
+
+	store.Set("third", &transport.CachedResponse{Expires: now.Add(3 * time.Hour)})
+	_, firstKept := store.Get("first")
+	_, secondKept := store.Get("second")
+	_, thirdKept := store.Get("third")
+
+	// THEN
+	assert.False(t, expiredKept, "expired entries should be evicted first")
+	assert.False(t, firstKept, "the entry expiring first should be evicted when none is expired")
+	assert.True(t, secondKept)
+	assert.True(t, thirdKept)
+}
+
+func TestWithHeaderFilter(t *testing.T) {
+	const apiID = "abc123"
+
+	tests := map[string]struct {
+		filter   transport.HeaderFilter
+		expected http.Header
+	}{
+		"deny list should drop matching headers": {
+			filter: transport.HeaderFilter{Deny: []string{"authorization", "X-Forwarded-*"}},
+			expected: http.Header{
+				"Content-Type": {"application/json"},
+				"X-Api-Key":    {"key"},
+			},
+		},
+		"allow list should keep only matching headers": {
+			filter: transport.HeaderFilter{Allow: []string{"Content-Type", "x-api-*"}},
+			expected: http.Header{
+				"Content-Type": {"application/json"},
+				"X-Api-Key":    {"key"},
+			},
+		},
+		"deny list should have precedence over allow list": {
+			filter:   transport.HeaderFilter{Allow: []string{"X-*"}, Deny: []string{"X-Forwarded-For"}},
+			expected: http.Header{"X-Api-Key": {"key"}},
+		},
+		"transform should change the filtered headers": {
+			filter: transport.HeaderFilter{
+				Allow: []string{"X-Api-Key"},
+				Transform: func(h http.Header) {
+					h.Set("X-Api-Key", "redacted")
+					h.Set("X-Source", "tests")
+				},
+			},
+			expected: http.Header{"X-Api-Key": {"redacted"}, "X-Source": {"tests"}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			httpReq := createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody)
+			httpReq.Header = http.Header{
+				"Authorization":   {"Bearer token"},
+				"Content-Type":    {"application/json"},
+				"X-Api-Key":       {"key"},
+				"X-Forwarded-For": {"10.0.0.1"},
+			}
+
+			original := httpReq.Header.Clone()
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+				Once()
+
+			apiGwCli.
+				On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+					return assert.ObjectsAreEqual(map[string][]string(tc.expected), in.MultiValueHeaders)
+				})).
+				Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+				Once()
+
+			tr := transport.NewTransport(apiGwCli, apiID, transport.WithHeaderFilter(tc.filter))
+
+			// WHEN
+			_, err := tr.RoundTrip(httpReq)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, original, httpReq.Header)
+
+			apiGwCli.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWithBasePathMappings(t *testing.T) {
+	// GIVEN
+	const (
+		apiID      = "abc123"
+		otherAPIID = "def456"
+	)
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(otherAPIID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("GetDomainNames", &apigateway.GetDomainNamesInput{}).
+		Return(&apigateway.GetDomainNamesOutput{Items: []types.DomainName{
+			{DomainName: aws.String("custom-domain.com")},
+		}}, nil).
+		Once()
+
+	apiGwCli.
+		On("GetBasePathMappings", &apigateway.GetBasePathMappingsInput{DomainName: aws.String("custom-domain.com")}).
+		Return(&apigateway.GetBasePathMappingsOutput{Items: []types.BasePathMapping{
+			{BasePath: aws.String("(none)"), RestApiId: aws.String(apiID), Stage: aws.String("prod")},
+			{BasePath: aws.String("v2"), RestApiId: aws.String(otherAPIID), Stage: aws.String("prod")},
+		}}, nil).
+		Once()
+
+	for _, id := range []string{apiID, otherAPIID} {
+		apiGwCli.
+			On("GetStage", &apigateway.GetStageInput{RestApiId: aws.String(id), StageName: aws.String("prod")}).
+			Return(&apigateway.GetStageOutput{Variables: map[string]string{"env": "prod"}}, nil).
+			Once()
+	}
+
+	prodVars := map[string]string{"env": "prod"}
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+			return *in.RestApiId == otherAPIID && *in.PathWithQueryString == "/api/v1/users/john.doe" &&
+				assert.ObjectsAreEqual(prodVars, in.StageVariables)
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+			return *in.RestApiId == apiID && *in.PathWithQueryString == "/api/v1/users/jane.doe" &&
+				assert.ObjectsAreEqual(prodVars, in.StageVariables)
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithBasePathMappings(),
+		transport.WithStage("dev"), transport.WithStageVariables(map[string]string{"env": "dev"}))
+
+	// WHEN
+	mappedResp, mappedErr := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/v2/api/v1/users/john.doe", http.NoBody))
+	defaultResp, defaultErr := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/jane.doe", http.NoBody))
+
+	// THEN
+	require.NoError(t, mappedErr)
+	require.NoError(t, defaultErr)
+
+	for _, resp := range []*http.Response{mappedResp, defaultResp} {
+		ic, _ := transport.InvokeContextFromContext(resp.Request.Context())
+		assert.Equal(t, "prod", ic.Stage, "the mapped stage should be invoked instead of the transport one")
+	}
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithStage(t *testing.T) {
+	const (
+		apiID     = "abc123"
+		invokeURL = "https://" + apiID + ".execute-api.us-east-1.amazonaws.com"
+	)
+
+	tests := map[string]string{
+		"stage prefix should be stripped":      "/dev/api/v1/users/john.doe",
+		"path without stage should be kept":    "/api/v1/users/john.doe",
+		"stage looking segment should be kept": "/api/v1/users/dev",
+	}
+
+	for name, path := range tests {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+				Once()
+
+			apiGwCli.
+				On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+					return *in.ResourceId == "2cb3ff" && strings.HasPrefix(*in.PathWithQueryString, "/api/v1/users/")
+				})).
+				Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+				Once()
+
+			tr := transport.NewTransport(apiGwCli, apiID, transport.WithStage("dev"))
+
+			// WHEN
+			_, err := tr.RoundTrip(createRequest(http.MethodGet, invokeURL, path, http.NoBody))
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, "dev", tr.Stage())
+
+			apiGwCli.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWithoutStageStripping(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+			return *in.ResourceId == "2cb3ff" && *in.PathWithQueryString == "/api/v1/users/john.doe"
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithoutStageStripping())
+
+	// WHEN
+	_, err := tr.RoundTrip(createRequest(http.MethodGet, "https://"+apiID+".execute-api.us-east-1.amazonaws.com",
+		"/api/v1/users/john.doe", http.NoBody))
+
+	// THEN
+	require.NoError(t, err, "the first segment should not be stripped")
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithInterceptor(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+			return assert.ObjectsAreEqual([]string{"Bearer token"}, in.MultiValueHeaders["Authorization"])
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	var calls []string
+
+	tracing := func(name string) transport.Interceptor {
+		return func(next transport.Invoker) transport.Invoker {
+			return transport.InvokerFunc(func(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error) {
+				calls = append(calls, name)
+				return next.Invoke(r, input)
+			})
+		}
+	}
+
+	auth := func(next transport.Invoker) transport.Invoker {
+		return transport.InvokerFunc(func(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error) {
+			input.MultiValueHeaders = map[string][]string{"Authorization": {"Bearer token"}}
+
+			resp, err := next.Invoke(r, input)
+			if err == nil {
+				resp.Header = http.Header{"X-Intercepted": {"true"}}
+			}
+
+			return resp, err
+		})
+	}
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithInterceptor(tracing("outer"), tracing("inner"), auth))
+
+	// WHEN
+	httpResp, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, "true", httpResp.Header.Get("X-Intercepted"))
+	assert.Equal(t, []string{"outer", "inner"}, calls)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestRoundTripGetBody(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusCreated}, nil).
+		Once()
+
+	var interceptedBody string
+
+	readBody := func(next transport.Invoker) transport.Invoker {
+		return transport.InvokerFunc(func(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error) {
+			body, err := r.GetBody()
+			require.NoError(t, err)
+
+			interceptedBody = readString(body)
+
+			return next.Invoke(r, input)
+		})
+	}
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithInterceptor(readBody))
+
+	// a body without GetBody, as http.NewRequest sets it for in-memory readers only
+	httpReq := createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users",
+		io.MultiReader(strings.NewReader(`{"name":`), strings.NewReader(`"john.doe"}`)))
+
+	// WHEN
+	httpResp, err := tr.RoundTrip(httpReq)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"john.doe"}`, interceptedBody)
+
+	require.NotNil(t, httpResp.Request.GetBody)
+
+	body, err := httpResp.Request.GetBody()
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"john.doe"}`, readString(body), "the response request body should be resendable")
+	assert.Equal(t, int64(19), httpResp.Request.ContentLength)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithRegion(t *testing.T) {
+	const apiID = "abc123"
+
+	tests := map[string]struct {
+		opt  transport.Option
+		host string
+	}{
+		"region":          {opt: transport.WithRegion("eu-west-1"), host: apiID + ".execute-api.eu-west-1.amazonaws.com"},
+		"china region":    {opt: transport.WithRegion("cn-north-1"), host: apiID + ".execute-api.cn-north-1.amazonaws.com.cn"},
+		"govcloud region": {opt: transport.WithRegion("us-gov-west-1"), host: apiID + ".execute-api.us-gov-west-1.amazonaws.com"},
+		"govcloud region alternate suffix": {
+			opt:  transport.WithRegion("us-gov-east-1"),
+			host: apiID + ".execute-api.us-gov-east-1.amazonaws-us-gov.com",
+		},
+		"iso region":      {opt: transport.WithRegion("us-iso-east-1"), host: apiID + ".execute-api.us-iso-east-1.c2s.ic.gov"},
+		"invoke URL host": {opt: transport.WithInvokeURLHost("API.internal.example.com"), host: "api.internal.example.com"},
+		"invoke host pattern": {
+			opt:  transport.WithInvokeHostPattern(regexp.MustCompile(`^gw-[a-z0-9]+\.corp\.internal$`)),
+			host: "gw-" + apiID + ".corp.internal:8443",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name+" should strip the stage of invoke URLs", func(t *testing.T) {
+			// GIVEN
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+				Once()
+
+			apiGwCli.
+				On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+					return *in.ResourceId == "2cb3ff" && *in.PathWithQueryString == "/api/v1/users/john.doe"
+				})).
+				Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+				Once()
+
+			tr := transport.NewTransport(apiGwCli, apiID, tc.opt)
+
+			// WHEN
+			_, err := tr.RoundTrip(createRequest(http.MethodGet, "https://"+tc.host, "/dev/api/v1/users/john.doe", http.NoBody))
+
+			// THEN
+			require.NoError(t, err)
+
+			apiGwCli.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWithResponseDecompression(t *testing.T) {
+	const apiID = "abc123"
+
+	gzipped := new(bytes.Buffer)
+	gzipWriter := gzip.NewWriter(gzipped)
+	_, _ = gzipWriter.Write([]byte(`{"name":"john.doe"}`))
+	_ = gzipWriter.Close()
+
+	deflated := new(bytes.Buffer)
+	zlibWriter := zlib.NewWriter(deflated)
+	_, _ = zlibWriter.Write([]byte(`{"name":"john.doe"}`))
+	_ = zlibWriter.Close()
+
+	tests := map[string]struct {
+		encoding string
+		body     string
+	}{
+		"gzip":    {encoding: "gzip", body: gzipped.String()},
+		"deflate": {encoding: "deflate", body: deflated.String()},
+	}
+
+	for name, tc := range tests {
+		t.Run(name+" body should be decompressed", func(t *testing.T) {
+			// GIVEN
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+				Once()
+
+			apiGwCli.
+				On("TestInvokeMethod", mock.Anything).
+				Return(&apigateway.TestInvokeMethodOutput{
+					Body:   aws.String(tc.body),
+					Status: http.StatusOK,
+					MultiValueHeaders: map[string][]string{
+						"Content-Encoding": {tc.encoding},
+						"Content-Length":   {strconv.Itoa(len(tc.body))},
+						"Content-Type":     {"application/json"},
+					},
+				}, nil).
+				Once()
+
+			tr := transport.NewTransport(apiGwCli, apiID, transport.WithResponseDecompression())
+
+			// WHEN
+			httpResp, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, `{"name":"john.doe"}`, readString(httpResp.Body))
+			assert.Equal(t, http.Header{"Content-Type": {"application/json"}}, httpResp.Header)
+			assert.Equal(t, int64(19), httpResp.ContentLength)
+			assert.True(t, httpResp.Uncompressed)
+
+			apiGwCli.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRoundTripCookies(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+			return *in.HttpMethod == http.MethodPost
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{
+			Body:   aws.String(""),
+			Status: http.StatusCreated,
+			MultiValueHeaders: map[string][]string{
+				"set-cookie": {
+					"session=s3cr3t; Path=/; HttpOnly",
+					"theme=dark; Path=/api; Expires=Wed, 21 Oct 2099 07:28:00 GMT",
+				},
+			},
+		}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+			return *in.HttpMethod == http.MethodGet &&
+				assert.ObjectsAreEqual([]string{"theme=dark; session=s3cr3t"}, in.MultiValueHeaders["Cookie"])
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+
+	httpCli := &http.Client{Transport: transport.NewTransport(apiGwCli, apiID), Jar: jar}
+
+	// WHEN
+	loginResp, err := httpCli.Post("https://custom-domain.com/api/v1/users", "application/json", strings.NewReader("{}"))
+	require.NoError(t, err)
+
+	sessionResp, err := httpCli.Get("https://custom-domain.com/api/v1/users/john.doe")
+	require.NoError(t, err)
+
+	// THEN
+	assert.Equal(t, []string{
+		"session=s3cr3t; Path=/; HttpOnly",
+		"theme=dark; Path=/api; Expires=Wed, 21 Oct 2099 07:28:00 GMT",
+	}, loginResp.Header.Values("Set-Cookie"))
+	assert.Len(t, loginResp.Cookies(), 2)
+	assert.Equal(t, http.StatusOK, sessionResp.StatusCode)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestRoundTripPrivateAPI(t *testing.T) {
+	const (
+		apiID    = "abc123"
+		vpceHost = "vpce-0a1b2c3d-e4f5g6h7.execute-api.us-east-1.vpce.amazonaws.com"
+	)
+
+	tests := map[string]func() *http.Request{
+		"api and vpc endpoint host": func() *http.Request {
+			return createRequest(http.MethodGet, "https://"+apiID+"-vpce-0a1b2c3d.execute-api.us-east-1.amazonaws.com",
+				"/dev/api/v1/users/john.doe", http.NoBody)
+		},
+		"vpc endpoint with host header": func() *http.Request {
+			r := createRequest(http.MethodGet, "https://"+vpceHost, "/dev/api/v1/users/john.doe", http.NoBody)
+			r.Host = apiID + ".execute-api.us-east-1.amazonaws.com"
+
+			return r
+		},
+		"vpc endpoint with api id header": func() *http.Request {
+			r := createRequest(http.MethodGet, "https://"+vpceHost, "/dev/api/v1/users/john.doe", http.NoBody)
+			r.Header.Set("x-apigw-api-id", apiID)
+
+			return r
+		},
+	}
+
+	for name, newRequest := range tests {
+		t.Run(name+" should strip the stage", func(t *testing.T) {
+			// GIVEN
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+				Once()
+
+			apiGwCli.
+				On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+					return *in.ResourceId == "2cb3ff" && *in.PathWithQueryString == "/api/v1/users/john.doe"
+				})).
+				Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+				Once()
+
+			tr := transport.NewTransport(apiGwCli, apiID)
+
+			// WHEN
+			_, err := tr.RoundTrip(newRequest())
+
+			// THEN
+			require.NoError(t, err)
+
+			apiGwCli.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWithSummaryFile(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	summaryPath := filepath.Join(t.TempDir(), "summary.json")
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(nil, &awshttp.ResponseError{ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusTooManyRequests}},
+			Err:      errors.New("too many requests"),
+		}}).
+		Once()
+
+	for _, latency := range []int64{120, 80} {
+		apiGwCli.
+			On("TestInvokeMethod", mock.Anything).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK, Latency: latency}, nil).
+			Once()
+	}
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithSummaryFile(summaryPath), transport.WithRetry(2, 0))
+
+	for _, path := range []string{"/api/v1/users/john.doe", "/api/v1/users/jane.doe", "/api/v1/posts"} {
+		_, _ = tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", path, http.NoBody))
+	}
+
+	// WHEN
+	err := tr.Close()
+
+	// THEN
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(summaryPath)
+	require.NoError(t, err)
+
+	var summary transport.Summary
+	require.NoError(t, json.Unmarshal(data, &summary))
+
+	assert.Equal(t, 3, summary.Requests)
+	assert.Equal(t, 2, summary.Invocations)
+	assert.Equal(t, map[string]int{"GET#/api/v1/users/{value}": 2}, summary.Routes)
+	assert.Equal(t, map[int]int{http.StatusOK: 2}, summary.Statuses)
+	assert.Equal(t, map[string]int{"resource_not_found": 1}, summary.Errors)
+	assert.Equal(t, transport.LatencySummary{P50: 80, P90: 120, P99: 120, Max: 120}, summary.LatencyMS)
+	assert.Equal(t, 5, summary.Coverage.Mapped)
+	assert.Equal(t, 1, summary.Coverage.Exercised)
+	assert.InDelta(t, 0.2, summary.Coverage.Ratio, 0.001)
+	assert.Len(t, summary.Coverage.Unexercised, 4)
+	assert.Equal(t, transport.BudgetSummary{TestInvokeCalls: 3, Retries: 1}, summary.Budget)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithQuietRoutes(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	buf := new(bytes.Buffer)
+	log := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	summaryPath := filepath.Join(t.TempDir(), "summary.json")
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+			return *i.HttpMethod == http.MethodGet
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Times(3)
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+			return *i.HttpMethod == http.MethodPost
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusCreated}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID,
+		transport.WithLogger(log),
+		transport.WithSummaryFile(summaryPath),
+		transport.WithQuietRoutes("GET#/api/v1/users/{value}"))
+
+	// WHEN
+	for range 3 {
+		_, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+		require.NoError(t, err)
+	}
+
+	_, err := tr.RoundTrip(createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users", strings.NewReader(`{}`)))
+	require.NoError(t, err)
+
+	require.NoError(t, tr.Close())
+
+	// THEN
+	assert.Equal(t, 1, strings.Count(buf.String(), `msg="invoke success"`))
+
+	data, err := os.ReadFile(summaryPath)
+	require.NoError(t, err)
+
+	var summary transport.Summary
+	require.NoError(t, json.Unmarshal(data, &summary))
+
+	assert.Equal(t, 1, summary.Requests)
+	assert.Equal(t, map[string]int{"POST#/api/v1/users": 1}, summary.Routes)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestTransport_Refresh(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()[:4]}, nil).
+		Once()
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(nil, errors.New("something went wrong")).
+		Once()
+
+	tr, err := transport.NewInitializedTransport(apiGwCli, apiID)
+	require.NoError(t, err)
+	require.Len(t, tr.Mappings(), 5)
+
+	// WHEN
+	refreshErr := tr.Refresh(context.Background())
+	refreshedMappings := tr.Mappings()
+
+	failedRefreshErr := tr.Refresh(context.Background())
+
+	// THEN
+	require.NoError(t, refreshErr)
+	assert.Len(t, refreshedMappings, 3, "mapping should be swapped")
+
+	assert.EqualError(t, failedRefreshErr, "get resources error: something went wrong")
+	assert.Len(t, tr.Mappings(), 3, "mapping should be kept on error")
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithMappingTTL(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+	apiGwCli := new(transporttest.Client)
+
+	postsResource := types.Resource{
+		Id:              aws.String("f00d01"),
+		Path:            aws.String("/api/v1/posts"),
+		ResourceMethods: map[string]types.Method{"GET": {}},
+	}
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	release := make(chan time.Time)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		WaitUntil(release).
+		Return(&apigateway.GetResourcesOutput{Items: append(createResources(), postsResource)}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil)
+
+	tr, err := transport.NewInitializedTransport(apiGwCli, apiID,
+		transport.WithClock(clock), transport.WithMappingTTL(time.Minute))
+	require.NoError(t, err)
+
+	postsRequest := func() (*http.Response, error) {
+		return tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/posts", http.NoBody))
+	}
+
+	_, err = postsRequest()
+	require.ErrorIs(t, err, transport.ErrResourceNotFound, "resource should not exist before the refresh")
+
+	// WHEN
+	clock.Advance(2 * time.Minute)
+
+	_, err = postsRequest()
+	require.ErrorIs(t, err, transport.ErrResourceNotFound, "expired request should not wait for the refresh")
+
+	close(release)
+
+	// THEN
+	assert.Eventually(t, func() bool {
+		_, err := postsRequest()
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithRefreshOnMiss(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+	apiGwCli := new(transporttest.Client)
+
+	postsResource := types.Resource{
+		Id:              aws.String("f00d01"),
+		Path:            aws.String("/api/v1/posts"),
+		ResourceMethods: map[string]types.Method{"GET": {}},
+	}
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Times(2)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: append(createResources(), postsResource)}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+			return *i.ResourceId == "f00d01"
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	tr, err := transport.NewInitializedTransport(apiGwCli, apiID,
+		transport.WithClock(clock), transport.WithRefreshOnMiss(time.Minute))
+	require.NoError(t, err)
+
+	postsRequest := func() (*http.Response, error) {
+		return tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/posts", http.NoBody))
+	}
+
+	// WHEN
+	_, firstErr := postsRequest()   // refreshes, but the resource is not deployed yet
+	_, limitedErr := postsRequest() // within the interval, no refresh
+
+	clock.Advance(time.Minute)
+
+	httpResp, err := postsRequest() // refreshes again and finds the resource
+
+	// THEN
+	assert.ErrorIs(t, firstErr, transport.ErrResourceNotFound)
+	assert.ErrorIs(t, limitedErr, transport.ErrResourceNotFound)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithClock(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	buf := new(bytes.Buffer)
+	log := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Run(func(mock.Arguments) { clock.Advance(3 * time.Second) }).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID,
+		transport.WithLogger(log),
+		transport.WithClock(clock),
+		transport.WithSlowInvokeThreshold(2*time.Second))
+
+	// WHEN
+	_, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), `level=WARN msg="slow invoke" rest_api_id=abc123 resource_id=2cb3ff duration=3s threshold=2s`)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.Advance(d)
+
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	return c.now
+}
+
+type metricsRecorder struct {
+	mu      sync.Mutex
+	invoked []string
+	failed  []string
+	missed  []string
+}
+
+func (m *metricsRecorder) Invoked(route transport.Route, status int, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.invoked = append(m.invoked, fmt.Sprintf("%s#%s %d", route.Method, route.Template, status))
+}
+
+func (m *metricsRecorder) Failed(errType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.failed = append(m.failed, errType)
+}
+
+func (m *metricsRecorder) MatchMissed(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.missed = append(m.missed, method)
+}
+
+func lastInvocation(t *testing.T, cli *transporttest.Client) transporttest.Invocation {
+	t.Helper()
+
+	invocation, found := cli.LastInvocation()
+	require.True(t, found, "no TestInvokeMethod call")
+
+	return invocation
+}
+
+func createResources() []types.Resource {
+	return []types.Resource{
+		{
+			Id:   aws.String("b7e20b3a4"),
+			Path: aws.String("/"),
+		},
+		{
+			Id:       aws.String("9b0826"),
+			ParentId: aws.String("b7e20b3a4"),
+			Path:     aws.String("/api"),
+			PathPart: aws.String("api"),
+		},
+		{
+			Id:       aws.String("7f4b77"),
+			ParentId: aws.String("9b0826"),
+			Path:     aws.String("/api/v1"),
+			PathPart: aws.String("v1"),
+		},
+		{
+			Id:              aws.String("8143a9"),
+			ParentId:        aws.String("7f4b77"),
+			Path:            aws.String("/api/v1/users"),
+			PathPart:        aws.String("users"),
+			ResourceMethods: map[string]types.Method{"POST": {}, "PUT": {}, "PATCH": {}},
+		},
+		{
+			Id:              aws.String("2cb3ff"),
+			ParentId:        aws.String("8143a9"),
+			Path:            aws.String("/api/v1/users/{value}"),
+			PathPart:        aws.String("{value}"),
+			ResourceMethods: map[string]types.Method{"GET": {}, "DELETE": {}},
+		},
+	}
+}
+
+func createRequest(method, domain, path string, body io.Reader) *http.Request {
+	httpReq, err := http.NewRequest(method, domain+path, body)
+	if err != nil {
+		panic(err)
+	}
+
+	httpReq.Header.Add("Content-Type", "application/json")
+	httpReq.Header.Add("X-Request-ID", "0123456789")
+	httpReq.Header.Add("X-User-Agent", "test_agent")
+
+	return httpReq
+}
+
+func matchTestInvoke(apiID, resourceID string, r *http.Request) func(*apigateway.TestInvokeMethodInput) bool {
+	return func(i *apigateway.TestInvokeMethodInput) bool {
+		if i == nil {
+			return false
+		}
+
+		// match api && resource
+		match := *i.RestApiId == apiID
+		match = match && *i.ResourceId == resourceID
+
+		// match method
+		match = match && *i.HttpMethod == r.Method
+
+		// match path
+		pathWithQueryString := r.URL.Path + "?" + r.URL.RawQuery
+		pathWithQueryString = strings.TrimSuffix(pathWithQueryString, "?")
+
+		if r.Host == apiID+".execute-api.us-east-1.amazonaws.com" {
+			pathWithQueryString = "/" + strings.Join(strings.Split(pathWithQueryString, "/")[2:], "/")
+		}
+
+		match = match && *i.PathWithQueryString == pathWithQueryString
+
+		// match body
+		header := r.Header.Clone()
+
+		if r.Body != nil && r.Body != http.NoBody {
+			body := readString(r.Body)
+			match = match && *i.Body == body
+			r.Body = io.NopCloser(strings.NewReader(body))
+
+			header.Set("Content-Length", strconv.Itoa(len(body)))
+		} else {
+			match = match && i.Body == nil
+		}
+
+		// match headers
+		match = match && len(i.MultiValueHeaders) == len(header)
+		for k, v := range i.MultiValueHeaders {
+			match = match && v[0] == header.Get(k)
+		}
+
+		return match
+	}
+}
+
+func readString(r io.Reader) string {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		panic(err)
+	}
+
+	return string(data)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestWithShadow(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", transporttest.InvokeOf(apiID, "8143a9")).
+		Return(&apigateway.TestInvokeMethodOutput{
+			Body:              aws.String(`{"id":1}`),
+			Status:            http.StatusCreated,
+			MultiValueHeaders: map[string][]string{"Content-Type": {"application/json"}, "Date": {"yesterday"}},
+		}, nil).
+		Twice()
+
+	var realBodies []string
+
+	realStatus := http.StatusCreated
+	realEndpoint := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		realBodies = append(realBodies, readString(r.Body))
+
+		return &http.Response{
+			StatusCode: realStatus,
+			Header:     http.Header{"Content-Type": {"text/plain"}, "Date": {"today"}},
+			Body:       io.NopCloser(strings.NewReader(`{"id":1}`)),
+		}, nil
+	})
+
+	var diffs []transport.ShadowDiff
+
+	tr := transport.NewTransport(apiGwCli, apiID,
+		transport.WithShadow(realEndpoint, func(diff transport.ShadowDiff) { diffs = append(diffs, diff) }))
+
+	post := func() (*http.Response, error) {
+		return tr.RoundTrip(createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users", strings.NewReader(`{"name":"john"}`)))
+	}
+
+	// WHEN
+	resp, err := post()
+	realStatus = http.StatusBadRequest
+	_, secondErr := post()
+
+	// THEN
+	require.NoError(t, err)
+	require.NoError(t, secondErr)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "the invoke response should be returned")
+	assert.Equal(t, `{"id":1}`, readString(resp.Body))
+	assert.Equal(t, []string{`{"name":"john"}`, `{"name":"john"}`}, realBodies)
+
+	require.Len(t, diffs, 2)
+	assert.Equal(t, "/api/v1/users", diffs[0].Route.Template)
+	assert.Equal(t, [2]int{http.StatusCreated, http.StatusCreated}, diffs[0].StatusCodes)
+	assert.Equal(t, []string{"Content-Type"}, diffs[0].Headers, "volatile headers should not be compared")
+	assert.False(t, diffs[0].BodyDiffers)
+	assert.Equal(t, [2]int{http.StatusCreated, http.StatusBadRequest}, diffs[1].StatusCodes)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithFault(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", transporttest.InvokeOf(apiID, "8143a9")).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusCreated}, nil).
+		Twice()
+
+	apiGwCli.
+		On("TestInvokeMethod", transporttest.InvokeOf(apiID, "2cb3ff")).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusNoContent}, nil)
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithClock(clock), transport.WithFaultSeed(42),
+		transport.WithFault("GET#/api/v1/users/{value}", transport.Fault{Latency: time.Second, StatusCode: http.StatusServiceUnavailable}),
+		transport.WithFault("POST#/api/v1/users", transport.Fault{Drop: true}),
+		transport.WithFault("PUT#/api/v1/users", transport.Fault{Probability: aws.Float64(0), StatusCode: http.StatusInternalServerError}),
+		transport.WithFault("DELETE#/api/v1/users/{value}", transport.Fault{Probability: aws.Float64(0.5), StatusCode: http.StatusInternalServerError}))
+
+	// WHEN
+	forced, forcedErr := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+	elapsed := clock.now.Sub(time.Unix(0, 0))
+
+	_, droppedErr := tr.RoundTrip(createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users", http.NoBody))
+
+	never, neverErr := tr.RoundTrip(createRequest(http.MethodPut, "https://custom-domain.com", "/api/v1/users", http.NoBody))
+
+	var injected int
+
+	for range 20 {
+		resp, err := tr.RoundTrip(createRequest(http.MethodDelete, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+		require.NoError(t, err)
+
+		if resp.StatusCode == http.StatusInternalServerError {
+			injected++
+		}
+	}
+
+	// THEN
+	require.NoError(t, forcedErr)
+	assert.Equal(t, http.StatusServiceUnavailable, forced.StatusCode)
+	assert.Equal(t, "", readString(forced.Body))
+	assert.Equal(t, time.Second, elapsed)
+
+	var invokeErr *transport.InvokeError
+
+	require.ErrorAs(t, droppedErr, &invokeErr)
+	assert.ErrorIs(t, droppedErr, transport.ErrResponseDropped)
+
+	require.NoError(t, neverErr)
+	assert.Equal(t, http.StatusCreated, never.StatusCode) // a zero probability never injects.
+
+	assert.Greater(t, injected, 0)
+	assert.Less(t, injected, 20)
+
+	apiGwCli.AssertNumberOfCalls(t, "TestInvokeMethod", 2+20-injected) // the forced responses do not invoke.
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithStages(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Times(3)
+
+	stages := map[string]string{"dev": "d3v", "qa": "q4"}
+	for stage, deployment := range stages {
+		apiGwCli.
+			On("GetStage", mock.MatchedBy(func(i *apigateway.GetStageInput) bool { return *i.StageName == stage })).
+			Return(&apigateway.GetStageOutput{DeploymentId: aws.String(deployment), Variables: map[string]string{"env": stage}}, nil).
+			Twice()
+	}
+
+	apiGwCli.
+		On("GetDeployment", mock.MatchedBy(func(i *apigateway.GetDeploymentInput) bool { return *i.DeploymentId == "d3v" })).
+		Return(&apigateway.GetDeploymentOutput{ApiSummary: map[string]map[string]types.MethodSnapshot{
+			"/api/v1/users/{value}": {"GET": {}, "DELETE": {}},
+		}}, nil).
+		Once()
+
+	apiGwCli.
+		On("GetDeployment", mock.MatchedBy(func(i *apigateway.GetDeploymentInput) bool { return *i.DeploymentId == "q4" })).
+		Return(&apigateway.GetDeploymentOutput{ApiSummary: map[string]map[string]types.MethodSnapshot{
+			"/api/v1/users/{value}": {"GET": {}},
+		}}, nil).
+		Once()
+
+	stageVarsOf := func(vars map[string]string) any {
+		return mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+			return assert.ObjectsAreEqual(vars, i.StageVariables)
+		})
+	}
+
+	apiGwCli.
+		On("TestInvokeMethod", stageVarsOf(map[string]string{"env": "dev", "debug": "true"})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusNoContent}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", stageVarsOf(map[string]string{"env": "qa"})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithStages("dev", "qa"),
+		transport.WithStageVariablesFor("dev", map[string]string{"debug": "true"}))
+
+	const invokeURL = "https://abc123.execute-api.us-east-1.amazonaws.com"
+
+	qaCtx := transport.NewInvokeContext(context.Background(), transport.InvokeContext{Stage: "qa"})
+
+	// WHEN
+	devResp, devErr := tr.RoundTrip(createRequest(http.MethodDelete, invokeURL, "/dev/api/v1/users/john.doe", http.NoBody))
+	_, qaErr := tr.RoundTrip(createRequest(http.MethodDelete, invokeURL, "/qa/api/v1/users/john.doe", http.NoBody))
+	qaResp, qaCtxErr := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody).WithContext(qaCtx))
+
+	// THEN
+	require.NoError(t, devErr)
+	assert.Equal(t, http.StatusNoContent, devResp.StatusCode)
+
+	var notFound *transport.ResourceNotFoundError
+
+	require.ErrorAs(t, qaErr, &notFound, "qa does not deploy DELETE")
+	assert.Equal(t, "/api/v1/users/john.doe", notFound.Path)
+
+	require.NoError(t, qaCtxErr)
+	assert.Equal(t, http.StatusOK, qaResp.StatusCode)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithInputModifier(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+			return aws.ToString(in.ClientCertificateId) == "cert-1" &&
+				assert.ObjectsAreEqual([]string{"tenant-a"}, in.MultiValueHeaders["X-Tenant-Id"])
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	modifierErr := errors.New("no tenant")
+
+	tr := transport.NewTransport(apiGwCli, apiID,
+		transport.WithInputModifier(func(_ context.Context, in *apigateway.TestInvokeMethodInput) error {
+			if aws.ToString(in.HttpMethod) == http.MethodDelete {
+				return modifierErr
+			}
+
+			in.MultiValueHeaders["X-Tenant-Id"] = []string{"tenant-a"}
+
+			return nil
+		}),
+		transport.WithInputModifier(func(_ context.Context, in *apigateway.TestInvokeMethodInput) error {
+			in.ClientCertificateId = aws.String("cert-1")
+			return nil
+		}))
+
+	// WHEN
+	resp, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+	_, deleteErr := tr.RoundTrip(createRequest(http.MethodDelete, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.ErrorIs(t, deleteErr, modifierErr)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithResponseModifier(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", transporttest.InvokeOf(apiID, "2cb3ff")).
+		Return(&apigateway.TestInvokeMethodOutput{
+			Body:              aws.String(`{}`),
+			Status:            http.StatusOK,
+			Latency:           12,
+			MultiValueHeaders: map[string][]string{"Content-Type": {"application/json"}},
+		}, nil).
+		Twice()
+
+	modifierErr := errors.New("rejected")
+
+	tr := transport.NewTransport(apiGwCli, apiID,
+		transport.WithResponseModifier(func(_ context.Context, resp *http.Response, out *apigateway.TestInvokeMethodOutput) error {
+			if resp.Request.Method == http.MethodDelete {
+				return modifierErr
+			}
+
+			resp.StatusCode = http.StatusAccepted
+			resp.Header.Set("X-Latency", strconv.FormatInt(out.Latency, 10))
+
+			return nil
+		}))
+
+	// WHEN
+	resp, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+	_, deleteErr := tr.RoundTrip(createRequest(http.MethodDelete, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "12", resp.Header.Get("X-Latency"))
+	assert.ErrorIs(t, deleteErr, modifierErr)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithDirectFallback(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	var sent []*http.Request
+
+	endpoint := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		sent = append(sent, r)
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody, Request: r}, nil
+	})
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithMaxBodySize(4),
+		transport.WithDirectFallback(transport.DirectFallback{
+			Transport: endpoint,
+			Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}, nil
+			}),
+			Region: "eu-west-1",
+		}))
+
+	upgrade := createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody)
+	upgrade.Header.Set("Connection", "Upgrade")
+	upgrade.Header.Set("Upgrade", "websocket")
+
+	large := createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users", io.NopCloser(strings.NewReader(`{"name":"john"}`)))
+
+	// WHEN
+	_, upgradeErr := tr.RoundTrip(upgrade)
+	_, largeErr := tr.RoundTrip(large)
+
+	// THEN
+	require.NoError(t, upgradeErr)
+	require.NoError(t, largeErr)
+	require.Len(t, sent, 2)
+
+	for _, r := range sent {
+		assert.Equal(t, "custom-domain.com", r.URL.Host)
+		assert.Contains(t, r.Header.Get("Authorization"), "Credential=AKID/")
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/execute-api/aws4_request")
+	}
+
+	assert.Equal(t, `{"name":"john"}`, readString(sent[1].Body), "the body of unknown length should be sent once buffered")
+
+	apiGwCli.AssertNotCalled(t, "TestInvokeMethod", mock.Anything)
+}
+
+func TestTransport_WarmUp(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID)
+	notReady := tr.Ready()
+
+	// WHEN
+	err := <-tr.WarmUp(context.Background())
+
+	// THEN
+	require.NoError(t, err)
+	assert.False(t, notReady)
+	assert.True(t, tr.Ready())
+	assert.NotEmpty(t, tr.Mappings())
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithAsyncInit(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(nil, errors.New("access denied")).
+		Once()
+
+	ready := make(chan error, 1)
+
+	// WHEN
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithAsyncInit(func(err error) { ready <- err }))
+
+	// THEN
+	assert.ErrorContains(t, <-ready, "access denied")
+	assert.False(t, tr.Ready())
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestTransport_Validate(t *testing.T) {
+	const apiID = "abc123"
+
+	statusErr := func(status int) error {
+		return &awshttp.ResponseError{ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+			Err:      errors.New(http.StatusText(status)),
+		}}
+	}
+
+	tests := map[string]struct {
+		resources      *apigateway.GetResourcesOutput
+		resourcesErr   error
+		invokeErr      error
+		expectedReport transport.ValidationReport
+	}{
+		"ready transport should pass": {
+			resources: &apigateway.GetResourcesOutput{Items: createResources()},
+			invokeErr: statusErr(http.StatusNotFound),
+			expectedReport: transport.ValidationReport{
+				APIID: apiID, APIFound: true, CanGetResources: true, CanTestInvoke: true, Routes: 5,
+			},
+		},
+		"missing test invoke permission should fail": {
+			resources: &apigateway.GetResourcesOutput{Items: createResources()},
+			invokeErr: statusErr(http.StatusForbidden),
+			expectedReport: transport.ValidationReport{
+				APIID: apiID, APIFound: true, CanGetResources: true, Routes: 5,
+				Problems: []string{"not allowed to test invoke (apigateway:POST)"},
+			},
+		},
+		"missing api should fail": {
+			resourcesErr: statusErr(http.StatusNotFound),
+			invokeErr:    statusErr(http.StatusNotFound),
+			expectedReport: transport.ValidationReport{
+				APIID: apiID, CanGetResources: true, CanTestInvoke: true,
+				Problems: []string{"rest api not found"},
+			},
+		},
+		"api without methods should fail": {
+			resources: &apigateway.GetResourcesOutput{Items: []types.Resource{{Id: aws.String("r00t"), Path: aws.String("/")}}},
+			invokeErr: statusErr(http.StatusNotFound),
+			expectedReport: transport.ValidationReport{
+				APIID: apiID, APIFound: true, CanGetResources: true, CanTestInvoke: true,
+				Problems: []string{"no routable methods"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(tc.resources, tc.resourcesErr).
+				Once()
+
+			apiGwCli.
+				On("TestInvokeMethod", transporttest.InvokeOf(apiID, "preflight-check")).
+				Return(nil, tc.invokeErr).
+				Once()
+
+			tr := transport.NewTransport(apiGwCli, apiID)
+
+			// WHEN
+			report, err := tr.Validate(context.Background())
+
+			// THEN
+			assert.Equal(t, tc.expectedReport, report)
+
+			if len(tc.expectedReport.Problems) > 0 {
+				assert.ErrorIs(t, err, transport.ErrValidationFailed)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			apiGwCli.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWithTrailingSlashEquivalence(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Twice()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+			return aws.ToString(in.ResourceId) == "2cb3ff" && aws.ToString(in.PathWithQueryString) == "/api/v1/users/john.doe?fields=name"
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithTrailingSlashEquivalence())
+	distinct := transport.NewTransport(apiGwCli, apiID)
+
+	req := func() *http.Request {
+		return createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe/?fields=name", http.NoBody)
+	}
+
+	// WHEN
+	resp, err := tr.RoundTrip(req())
+	_, distinctErr := distinct.RoundTrip(req())
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.ErrorIs(t, distinctErr, transport.ErrResourceNotFound, "trailing slashes should be distinct by default")
+
+	route, matched := tr.MatchResource(http.MethodGet, "/api/v1/users/john.doe//")
+	assert.True(t, matched)
+	assert.Equal(t, "/api/v1/users/{value}", route.Template)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithPathPrefixFilter(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	resources := append(createResources(), types.Resource{
+		Id:              aws.String("0rd3r5"),
+		Path:            aws.String("/api/v1/users-search"),
+		PathPart:        aws.String("users-search"),
+		ResourceMethods: map[string]types.Method{"GET": {}},
+	})
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: resources}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithPathPrefixFilter("/api/v1/users/"),
+		transport.WithMappingSources(
+			transport.ResourcesSource(apiGwCli, apiID),
+			transport.StaticSource(transport.Route{Method: http.MethodGet, Template: "/health", ResourceID: "h3a1th"})))
+
+	// WHEN
+	err := <-tr.WarmUp(context.Background())
+
+	// THEN
+	require.NoError(t, err)
+
+	var templates []string
+	for _, route := range tr.Routes() {
+		templates = append(templates, route.Method+" "+route.Template)
+	}
+
+	assert.Equal(t, []string{
+		"PATCH /api/v1/users",
+		"POST /api/v1/users",
+		"PUT /api/v1/users",
+		"DELETE /api/v1/users/{value}",
+		"GET /api/v1/users/{value}",
+	}, templates)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+type blockingSource struct {
+	name    string
+	routes  []transport.Route
+	started *sync.WaitGroup
+}
+
+func (s blockingSource) Name() string { return s.name }
+
+func (s blockingSource) Routes(context.Context) ([]transport.Route, error) {
+	s.started.Done()
+	s.started.Wait() // every source must be fetched at once to return
+
+	return s.routes, nil
+}
+
+func TestWithInitConcurrency(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	started := new(sync.WaitGroup)
+	started.Add(2)
+
+	route := func(resourceID string) transport.Route {
+		return transport.Route{Method: http.MethodGet, Template: "/health", ResourceID: resourceID}
+	}
+
+	tr := transport.NewTransport(new(transporttest.Client), apiID, transport.WithInitConcurrency(2),
+		transport.WithMappingSources(
+			blockingSource{name: "first", routes: []transport.Route{route("f1r5t")}, started: started},
+			blockingSource{name: "second", routes: []transport.Route{route("s3c0nd")}, started: started}))
+
+	// WHEN
+	err := <-tr.WarmUp(context.Background())
+
+	// THEN
+	require.NoError(t, err)
+
+	matched, found := tr.MatchResource(http.MethodGet, "/health")
+	assert.True(t, found)
+	assert.Equal(t, "f1r5t", matched.ResourceID, "the first source should keep precedence")
+}
+
+func TestWithInitConcurrency_SingleSource(t *testing.T) {
+	// GIVEN
+	initBuf := new(bytes.Buffer)
+	initLog := slog.New(slog.NewTextHandler(initBuf, nil))
+
+	// WHEN
+	transport.NewTransport(new(transporttest.Client), "abc123", transport.WithInitConcurrency(4), transport.WithInitLogger(initLog))
+
+	// THEN
+	assert.Contains(t, initBuf.String(), `level=WARN msg="init concurrency has no effect with a single mapping source"`)
+	assert.Contains(t, initBuf.String(), "init_concurrency=4")
+}
+
+func TestTransport_Watch(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	redeployed := append(createResources()[:4], types.Resource{
+		Id:              aws.String("0rd3r5"),
+		Path:            aws.String("/api/v1/orders"),
+		PathPart:        aws.String("orders"),
+		ResourceMethods: map[string]types.Method{"GET": {}},
+	})
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Twice()
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: redeployed}, nil).
+		Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var diffs []transport.RouteDiff
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithClock(&fakeClock{now: time.Unix(0, 0)}),
+		transport.WithOnRefresh(func(diff transport.RouteDiff) {
+			diffs = append(diffs, diff)
+			cancel()
+		}))
+
+	// WHEN
+	err := tr.Watch(ctx, time.Minute)
+
+	// THEN
+	assert.ErrorIs(t, err, context.Canceled)
+	require.Len(t, diffs, 1, "refreshes without changes should not be reported")
+
+	assert.Equal(t, []transport.Route{{Method: http.MethodGet, Template: "/api/v1/orders", ResourceID: "0rd3r5"}}, diffs[0].Added)
+	assert.Len(t, diffs[0].Removed, 2)
+
+	_, found := tr.MatchResource(http.MethodGet, "/api/v1/orders")
+	assert.True(t, found)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithErrorOnStatus(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", transporttest.InvokeOf(apiID, "2cb3ff")).
+		Return(&apigateway.TestInvokeMethodOutput{
+			Body:              aws.String(`{"message":"boom"}`),
+			Status:            http.StatusBadGateway,
+			MultiValueHeaders: map[string][]string{"Content-Type": {"application/json"}},
+		}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", transporttest.InvokeOf(apiID, "8143a9")).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusBadRequest}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithErrorOnStatus(func(status int) bool { return status >= 500 }))
+
+	// WHEN
+	resp, err := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+	clientErrResp, clientErr := tr.RoundTrip(createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users", http.NoBody))
+
+	// THEN
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, transport.ErrUnexpectedStatus)
+
+	var statusErr *transport.StatusError
+
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusBadGateway, statusErr.Status)
+	assert.Equal(t, `{"message":"boom"}`, string(statusErr.Body))
+	assert.Equal(t, "application/json", statusErr.Header.Get("Content-Type"))
+	assert.Equal(t, "/api/v1/users/{value}", statusErr.Route.Template)
+	assert.EqualError(t, err, "unexpected status: GET /api/v1/users/john.doe responded 502 Bad Gateway")
+
+	require.NoError(t, clientErr, "statuses not configured should be returned as responses")
+	assert.Equal(t, http.StatusBadRequest, clientErrResp.StatusCode)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithRetryAfter(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	start := time.Unix(0, 0)
+	clock := &fakeClock{now: start}
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	throttled := func(retryAfter string) *apigateway.TestInvokeMethodOutput {
+		return &apigateway.TestInvokeMethodOutput{
+			Body:              aws.String(""),
+			Status:            http.StatusTooManyRequests,
+			MultiValueHeaders: map[string][]string{"Retry-After": {retryAfter}},
+		}
+	}
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(throttled("2"), nil).Once().
+		On("TestInvokeMethod", mock.Anything).
+		Return(throttled(start.Add(time.Hour).UTC().Format(http.TimeFormat)), nil).Once().
+		On("TestInvokeMethod", mock.Anything).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusCreated}, nil).Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithClock(clock), transport.WithRetryAfter(3, time.Minute))
+
+	// WHEN
+	resp, err := tr.RoundTrip(createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users", strings.NewReader(`{"name":"john"}`)))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, 2*time.Second+time.Minute, clock.Now().Sub(start), "waits should be bounded by the max wait")
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestTransport_RoundTrip_RequestHost(t *testing.T) {
+	// GIVEN
+	const (
+		apiID      = "abc123"
+		otherAPIID = "def456"
+	)
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(otherAPIID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("GetDomainNames", &apigateway.GetDomainNamesInput{}).
+		Return(&apigateway.GetDomainNamesOutput{Items: []types.DomainName{
+			{DomainName: aws.String("custom-domain.com")},
+		}}, nil).
+		Once()
+
+	apiGwCli.
+		On("GetBasePathMappings", &apigateway.GetBasePathMappingsInput{DomainName: aws.String("custom-domain.com")}).
+		Return(&apigateway.GetBasePathMappingsOutput{Items: []types.BasePathMapping{
+			{BasePath: aws.String("v2"), RestApiId: aws.String(otherAPIID), Stage: aws.String("prod")},
+		}}, nil).
+		Once()
+
+	apiGwCli.
+		On("GetStage", &apigateway.GetStageInput{RestApiId: aws.String(otherAPIID), StageName: aws.String("prod")}).
+		Return(&apigateway.GetStageOutput{}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+			return *in.RestApiId == apiID && *in.PathWithQueryString == "/api/v1/users/jane.doe"
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+			return *in.RestApiId == otherAPIID && *in.PathWithQueryString == "/api/v1/users/john.doe"
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithStage("dev"),
+		transport.WithBasePathMappings(), transport.WithForwardedHost())
+
+	serverReq := createRequest(http.MethodGet, "", "/dev/api/v1/users/jane.doe", http.NoBody)
+	serverReq.Host = apiID + ".execute-api.us-east-1.amazonaws.com:443"
+
+	proxiedReq := createRequest(http.MethodGet, "http://localhost:8080", "/v2/api/v1/users/john.doe", http.NoBody)
+	proxiedReq.Header.Set("X-Forwarded-Host", "custom-domain.com, proxy.internal")
+
+	// WHEN
+	_, serverErr := tr.RoundTrip(serverReq)
+	_, proxiedErr := tr.RoundTrip(proxiedReq)
+
+	// THEN
+	assert.NoError(t, serverErr)
+	assert.NoError(t, proxiedErr)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithLocalStack(t *testing.T) {
+	const apiID = "abc123"
+
+	tests := map[string]string{
+		"invoke URL host should have the stage stripped": "http://" + apiID + ".execute-api.localhost.localstack.cloud:4566/dev/api/v1/users/john.doe",
+		"user request path should be stripped":           "http://localhost:4566/restapis/" + apiID + "/dev/_user_request_/api/v1/users/john.doe",
+	}
+
+	for name, endpoint := range tests {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+				Once()
+
+			apiGwCli.
+				On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+					return *in.ResourceId == "2cb3ff" && *in.PathWithQueryString == "/api/v1/users/john.doe"
+				})).
+				Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+				Once()
+
+			tr := transport.NewTransport(apiGwCli, apiID, transport.WithLocalStack())
+
+			req, err := http.NewRequest(http.MethodGet, endpoint, http.NoBody)
+			require.NoError(t, err)
+
+			// WHEN
+			_, err = tr.RoundTrip(req)
+
+			// THEN
+			require.NoError(t, err)
+
+			apiGwCli.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWithInitRetry(t *testing.T) {
+	const apiID = "abc123"
+
+	t.Run("failed initialization should be retried after the backoff", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(nil, errors.New("access denied")).
+			Twice()
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+			Once()
+
+		apiGwCli.
+			On("TestInvokeMethod", mock.Anything).
+			Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil)
+
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithClock(clock), transport.WithInitRetry(0, time.Second))
+
+		roundTrip := func() error {
+			_, err := tr.RoundTrip(createRequest(http.MethodGet, "https://"+apiID+".execute-api.us-east-1.amazonaws.com",
+				"/dev/api/v1/users/john.doe", http.NoBody))
+
+			return err
+		}
+
+		// WHEN
+		firstErr := roundTrip()
+		backoffErr := roundTrip()
+
+		clock.Advance(time.Second)
+		secondErr := roundTrip()
+
+		clock.Advance(time.Second)
+		doubledBackoffErr := roundTrip()
+
+		clock.Advance(time.Second)
+		recoveredErr := roundTrip()
+
+		// THEN
+		assert.ErrorContains(t, firstErr, "access denied")
+		assert.ErrorContains(t, backoffErr, "access denied")
+		assert.ErrorContains(t, secondErr, "access denied")
+		assert.ErrorContains(t, doubledBackoffErr, "access denied")
+		assert.NoError(t, recoveredErr)
+		assert.True(t, tr.Ready())
+
+		apiGwCli.AssertExpectations(t)
+		apiGwCli.AssertNumberOfCalls(t, "GetResources", 3)
+	})
+
+	t.Run("initialization should give up after the max attempts", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(transporttest.Client)
+
+		apiGwCli.
+			On("GetResources", transporttest.GetResourcesFor(apiID)).
+			Return(nil, errors.New("access denied")).
+			Twice()
+
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		tr := transport.NewTransport(apiGwCli, apiID, transport.WithClock(clock), transport.WithInitRetry(2, time.Second))
+
+		// WHEN
+		_, firstErr := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+		clock.Advance(time.Second)
+		_, secondErr := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+		clock.Advance(time.Hour)
+		_, lastErr := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+		// THEN
+		assert.ErrorContains(t, firstErr, "access denied")
+		assert.ErrorContains(t, secondErr, "access denied")
+		assert.ErrorContains(t, lastErr, "access denied")
+		assert.False(t, tr.Ready())
+
+		apiGwCli.AssertExpectations(t)
+	})
+}
+
+func TestTransport_Stats(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(nil, errors.New("something went wrong")).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(nil, errors.New("access denied")).
+		Once()
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithClock(clock))
+
+	// WHEN
+	_, _ = tr.RoundTrip(createRequest(http.MethodGet, "", "/api/v1/users/john.doe", http.NoBody))
+	_, _ = tr.RoundTrip(createRequest(http.MethodGet, "", "/api/v1/users/jane.doe", http.NoBody))
+	_, _ = tr.RoundTrip(createRequest(http.MethodGet, "", "/api/v2/users", http.NoBody))
+
+	refreshErr := tr.Refresh(context.Background())
+	stats := tr.Stats()
+
+	// THEN
+	assert.Equal(t, transport.Stats{
+		Ready:        true,
+		Routes:       5,
+		RefreshedAt:  time.Unix(0, 0),
+		RefreshErr:   refreshErr,
+		MatchHits:    2,
+		MatchMisses:  1,
+		Invocations:  2,
+		InvokeErrors: 1,
+	}, stats)
+	assert.Error(t, refreshErr)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestContextWithLogger(t *testing.T) {
+	// GIVEN
+	transportBuf, requestBuf := new(bytes.Buffer), new(bytes.Buffer)
+	transportLog := slog.New(slog.NewTextHandler(transportBuf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	requestLog := slog.New(slog.NewTextHandler(requestBuf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor("abc123")).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusOK}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, "abc123", transport.WithLogger(transportLog),
+		transport.WithLogAttrs(slog.String("service", "users")))
+
+	req := createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody)
+	req = req.WithContext(transport.ContextWithLogger(req.Context(), requestLog.With(slog.String("trace_id", "t-1"))))
+
+	// WHEN
+	_, err := tr.RoundTrip(req)
+
+	// THEN
+	require.NoError(t, err)
+
+	assert.Contains(t, requestBuf.String(), `msg="invoke success" trace_id=t-1 rest_api_id=abc123 service=users`)
+	assert.NotContains(t, transportBuf.String(), `msg="invoke success"`)
+	assert.Contains(t, transportBuf.String(), `msg="mappings ready" rest_api_id=abc123 service=users`)
+}
+
+func TestTransport_RoundTrip_WithoutBody(t *testing.T) {
+	// GIVEN
+	buf := new(bytes.Buffer)
+	log := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor("abc123")).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.Anything).
+		Return(&apigateway.TestInvokeMethodOutput{Status: http.StatusNoContent}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, "abc123", transport.WithLogger(log))
+
+	// WHEN
+	resp, err := tr.RoundTrip(createRequest(http.MethodDelete, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Empty(t, readString(resp.Body))
+	assert.Contains(t, buf.String(), `api_gw_output.body="(no body)"`)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithLambdaProxy(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+			return *in.ResourceId == "8143a9"
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusCreated}, nil).
+		Once()
+
+	var (
+		function string
+		event    map[string]any
+	)
+
+	lambdaCli := transport.LambdaClientFunc(func(_ context.Context, fn string, payload []byte) ([]byte, error) {
+		function = fn
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, err
+		}
+
+		return []byte(`{"statusCode":200,"headers":{"Content-Type":"text/plain"},` +
+			`"multiValueHeaders":{"Set-Cookie":["a=1","b=2"]},"body":"aGVsbG8=","isBase64Encoded":true}`), nil
+	})
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithStage("dev"),
+		transport.WithLambdaProxy(lambdaCli, "users-function", "GET#/api/v1/users/{value}"))
+
+	// WHEN
+	resp, err := tr.RoundTrip(createRequest(http.MethodGet, "https://"+apiID+".execute-api.us-east-1.amazonaws.com",
+		"/dev/api/v1/users/john.doe?fields=id&fields=name", http.NoBody))
+	require.NoError(t, err)
+
+	_, postErr := tr.RoundTrip(createRequest(http.MethodPost, "https://"+apiID+".execute-api.us-east-1.amazonaws.com",
+		"/dev/api/v1/users", strings.NewReader(`{"name":"john.doe"}`)))
+
+	// THEN
+	require.NoError(t, postErr)
+
+	assert.Equal(t, "users-function", function)
+	assert.Equal(t, "/api/v1/users/{value}", event["resource"])
+	assert.Equal(t, "/api/v1/users/john.doe", event["path"])
+	assert.Equal(t, http.MethodGet, event["httpMethod"])
+	assert.Equal(t, map[string]any{"value": "john.doe"}, event["pathParameters"])
+	assert.Equal(t, map[string]any{"fields": "name"}, event["queryStringParameters"])
+	assert.Equal(t, map[string]any{"fields": []any{"id", "name"}}, event["multiValueQueryStringParameters"])
+	assert.Equal(t, "test_agent", event["headers"].(map[string]any)["X-User-Agent"])
+	assert.Nil(t, event["body"])
+
+	requestContext := event["requestContext"].(map[string]any)
+	assert.Equal(t, "2cb3ff", requestContext["resourceId"])
+	assert.Equal(t, "dev", requestContext["stage"])
+	assert.Equal(t, "/dev/api/v1/users/john.doe", requestContext["path"])
+	assert.Equal(t, apiID, requestContext["apiId"])
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "hello", readString(resp.Body))
+	assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+	assert.Equal(t, []string{"a=1", "b=2"}, resp.Header.Values("Set-Cookie"))
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestWithInvoker(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+			return *in.ResourceId == "8143a9"
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusCreated}, nil).
+		Once()
+
+	var invokedRoute transport.Route
+
+	fake := transport.InvokerFunc(func(r *http.Request, input *apigateway.TestInvokeMethodInput) (*http.Response, error) {
+		invokedRoute, _ = transport.RouteFromContext(r.Context())
+
+		return &http.Response{
+			StatusCode: http.StatusTeapot,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(aws.ToString(input.PathWithQueryString))),
+			Request:    r,
+		}, nil
+	})
+
+	tr := transport.NewTransport(apiGwCli, apiID, transport.WithInvoker(fake, "GET#/api/v1/users/{value}"))
+
+	// WHEN
+	fakeResp, fakeErr := tr.RoundTrip(createRequest(http.MethodGet, "https://custom-domain.com", "/api/v1/users/john.doe", http.NoBody))
+	defaultResp, defaultErr := tr.RoundTrip(createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users",
+		strings.NewReader(`{"name":"john.doe"}`)))
+
+	// THEN
+	require.NoError(t, fakeErr)
+	require.NoError(t, defaultErr)
+
+	assert.Equal(t, http.StatusTeapot, fakeResp.StatusCode)
+	assert.Equal(t, "/api/v1/users/john.doe", readString(fakeResp.Body))
+	assert.Equal(t, "2cb3ff", invokedRoute.ResourceID)
+	assert.Equal(t, http.StatusCreated, defaultResp.StatusCode)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestTransport_RoundTrip_Multipart(t *testing.T) {
+	const apiID = "abc123"
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	upload := func(fileContentType string, file []byte) (string, []byte) {
+		body := new(bytes.Buffer)
+		w := multipart.NewWriter(body)
+
+		_ = w.WriteField("name", "john.doe")
+
+		part, _ := w.CreatePart(textproto.MIMEHeader{
+			"Content-Disposition": {`form-data; name="avatar"; filename="avatar"`},
+			"Content-Type":        {fileContentType},
+		})
+		_, _ = part.Write(file)
+		_ = w.Close()
+
+		return w.FormDataContentType(), body.Bytes()
+	}
+
+	tests := map[string]struct {
+		fileContentType string
+		file            []byte
+		expectedBase64  bool
+	}{
+		"text upload should be sent verbatim":    {fileContentType: "text/plain", file: []byte("hello\r\n--not a boundary")},
+		"binary upload should be base64 encoded": {fileContentType: "image/png", file: png, expectedBase64: true},
+		"binary media type part should be base64 encoded": {
+			fileContentType: "application/octet-stream",
+			file:            []byte("plain ascii bytes"),
+			expectedBase64:  true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			contentType, body := upload(tc.fileContentType, tc.file)
+
+			expectedBody := string(body)
+			if tc.expectedBase64 {
+				expectedBody = base64.StdEncoding.EncodeToString(body)
+			}
+
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+				Once()
+
+			apiGwCli.
+				On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+					return aws.ToString(in.Body) == expectedBody &&
+						slices.Equal(in.MultiValueHeaders["Content-Type"], []string{contentType})
+				})).
+				Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(""), Status: http.StatusCreated}, nil).
+				Once()
+
+			tr := transport.NewTransport(apiGwCli, apiID)
+
+			req := createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users", bytes.NewReader(body))
+			req.Header.Set("Content-Type", contentType)
+
+			// WHEN
+			_, err := tr.RoundTrip(req)
+
+			// THEN
+			require.NoError(t, err)
+
+			apiGwCli.AssertExpectations(t)
+		})
+	}
+
+	contentType, body := upload("image/png", png)
+
+	malformed := map[string]struct {
+		contentType string
+		body        []byte
+	}{
+		"truncated body should fail":   {contentType: contentType, body: body[:len(body)/2]},
+		"wrong boundary should fail":   {contentType: "multipart/form-data; boundary=not-the-boundary", body: body},
+		"missing boundary should fail": {contentType: "multipart/form-data", body: body},
+	}
+
+	for name, tc := range malformed {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			apiGwCli := new(transporttest.Client)
+
+			apiGwCli.
+				On("GetResources", transporttest.GetResourcesFor(apiID)).
+				Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+				Once()
+
+			tr := transport.NewTransport(apiGwCli, apiID)
+
+			req := createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users", bytes.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+
+			// WHEN
+			_, err := tr.RoundTrip(req)
+
+			// THEN
+			assert.ErrorIs(t, err, transport.ErrInvalidMultipart)
+
+			apiGwCli.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTransport_RoundTrip_ContentLength(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+			return slices.Equal(in.MultiValueHeaders["Content-Length"], []string{"19"}) &&
+				in.MultiValueHeaders["Transfer-Encoding"] == nil
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{
+			Body: aws.String(`{"id":"42"}`),
+			MultiValueHeaders: map[string][]string{
+				"content-length":    {"999"},
+				"transfer-encoding": {"chunked"},
+			},
+			Status: http.StatusCreated,
+		}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID)
+
+	req := createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users", strings.NewReader(`{"name":"john.doe"}`))
+	req.Header.Set("Transfer-Encoding", "chunked")
+
+	// WHEN
+	resp, err := tr.RoundTrip(req)
+
+	// THEN
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(11), resp.ContentLength)
+	assert.Equal(t, "11", resp.Header.Get("Content-Length"))
+	assert.Empty(t, resp.Header.Get("Transfer-Encoding"))
+	assert.Empty(t, req.Header.Get("Content-Length"), "request headers should not be modified")
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestTransport_RoundTrip_UntrustedContentLength(t *testing.T) {
+	// GIVEN
+	const apiID = "abc123"
+
+	apiGwCli := new(transporttest.Client)
+
+	apiGwCli.
+		On("GetResources", transporttest.GetResourcesFor(apiID)).
+		Return(&apigateway.GetResourcesOutput{Items: createResources()}, nil).
+		Once()
+
+	apiGwCli.
+		On("TestInvokeMethod", mock.MatchedBy(func(in *apigateway.TestInvokeMethodInput) bool {
+			return aws.ToString(in.Body) == "{}" && slices.Equal(in.MultiValueHeaders["Content-Length"], []string{"2"})
+		})).
+		Return(&apigateway.TestInvokeMethodOutput{Body: aws.String(`{"id":"42"}`), Status: http.StatusCreated}, nil).
+		Once()
+
+	tr := transport.NewTransport(apiGwCli, apiID)
+
+	req := createRequest(http.MethodPost, "https://custom-domain.com", "/api/v1/users", strings.NewReader("{}"))
+	req.ContentLength = 1 << 45
+
+	// WHEN
+	resp, err := tr.RoundTrip(req)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	apiGwCli.AssertExpectations(t)
 }
@@ -0,0 +1,166 @@
+// Package transporttest provides an API Gateway client mock and an in-memory fake to test code
+// using the transport.
+package transporttest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/stretchr/testify/mock"
+)
+
+// Region is the region of the [Client] options unless set.
+const Region = "us-east-1"
+
+// Client is a testify mock of the API Gateway client, implementing transport.ApiGwClient and the
+// optional client interfaces (transport.StageGetter, transport.DeploymentGetter, ...).
+// Expectations are set with the embedded [mock.Mock], on the input arguments only:
+//
+//	cli := new(transporttest.Client)
+//	cli.On("GetResources", transporttest.GetResourcesFor("abc123")).Return(out, nil)
+//
+// The invokes are captured, see [Client.Invocations].
+type Client struct {
+	mock.Mock
+
+	// Region is the region of the client options, [Region] when empty.
+	Region string
+
+	mu          sync.Mutex
+	invocations []Invocation
+}
+
+// Invocation is a captured TestInvokeMethod call.
+type Invocation struct {
+	Context context.Context
+	Input   *apigateway.TestInvokeMethodInput
+	// Options are the client options, with the per-call option functions applied.
+	Options apigateway.Options
+}
+
+// Invocations returns the captured TestInvokeMethod calls, in call order.
+func (c *Client) Invocations() []Invocation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Invocation(nil), c.invocations...)
+}
+
+// LastInvocation returns the last captured TestInvokeMethod call, false when there was none.
+func (c *Client) LastInvocation() (Invocation, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.invocations) == 0 {
+		return Invocation{}, false
+	}
+
+	return c.invocations[len(c.invocations)-1], true
+}
+
+func (c *Client) TestInvokeMethod(
+	ctx context.Context,
+	input *apigateway.TestInvokeMethodInput,
+	optFns ...func(*apigateway.Options),
+) (*apigateway.TestInvokeMethodOutput, error) {
+	opts := c.Options()
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	c.mu.Lock()
+	c.invocations = append(c.invocations, Invocation{Context: ctx, Input: input, Options: opts})
+	c.mu.Unlock()
+
+	return result[*apigateway.TestInvokeMethodOutput](c.Called(input))
+}
+
+func (c *Client) GetResources(
+	_ context.Context,
+	input *apigateway.GetResourcesInput,
+	_ ...func(*apigateway.Options),
+) (*apigateway.GetResourcesOutput, error) {
+	return result[*apigateway.GetResourcesOutput](c.Called(input))
+}
+
+func (c *Client) GetStage(
+	_ context.Context,
+	input *apigateway.GetStageInput,
+	_ ...func(*apigateway.Options),
+) (*apigateway.GetStageOutput, error) {
+	return result[*apigateway.GetStageOutput](c.Called(input))
+}
+
+func (c *Client) GetDeployment(
+	_ context.Context,
+	input *apigateway.GetDeploymentInput,
+	_ ...func(*apigateway.Options),
+) (*apigateway.GetDeploymentOutput, error) {
+	return result[*apigateway.GetDeploymentOutput](c.Called(input))
+}
+
+func (c *Client) GetDomainNames(
+	_ context.Context,
+	input *apigateway.GetDomainNamesInput,
+	_ ...func(*apigateway.Options),
+) (*apigateway.GetDomainNamesOutput, error) {
+	return result[*apigateway.GetDomainNamesOutput](c.Called(input))
+}
+
+func (c *Client) GetRestApis(
+	_ context.Context,
+	input *apigateway.GetRestApisInput,
+	_ ...func(*apigateway.Options),
+) (*apigateway.GetRestApisOutput, error) {
+	return result[*apigateway.GetRestApisOutput](c.Called(input))
+}
+
+func (c *Client) GetExport(
+	_ context.Context,
+	input *apigateway.GetExportInput,
+	_ ...func(*apigateway.Options),
+) (*apigateway.GetExportOutput, error) {
+	return result[*apigateway.GetExportOutput](c.Called(input))
+}
+
+func (c *Client) GetBasePathMappings(
+	_ context.Context,
+	input *apigateway.GetBasePathMappingsInput,
+	_ ...func(*apigateway.Options),
+) (*apigateway.GetBasePathMappingsOutput, error) {
+	return result[*apigateway.GetBasePathMappingsOutput](c.Called(input))
+}
+
+func (c *Client) Options() apigateway.Options {
+	if c.Region == "" {
+		return apigateway.Options{Region: Region}
+	}
+
+	return apigateway.Options{Region: c.Region}
+}
+
+func result[T any](args mock.Arguments) (T, error) {
+	var out T
+
+	if args.Get(0) != nil {
+		out = args.Get(0).(T)
+	}
+
+	return out, args.Error(1)
+}
+
+// GetResourcesFor matches the GetResources inputs of apiID.
+func GetResourcesFor(apiID string) any {
+	return mock.MatchedBy(func(i *apigateway.GetResourcesInput) bool {
+		return i != nil && aws.ToString(i.RestApiId) == apiID
+	})
+}
+
+// InvokeOf matches the TestInvokeMethod inputs of the apiID resource.
+func InvokeOf(apiID, resourceID string) any {
+	return mock.MatchedBy(func(i *apigateway.TestInvokeMethodInput) bool {
+		return i != nil && aws.ToString(i.RestApiId) == apiID && aws.ToString(i.ResourceId) == resourceID
+	})
+}
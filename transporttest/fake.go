@@ -0,0 +1,169 @@
+package transporttest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+)
+
+// Fake is an in-memory API Gateway serving the routes registered with [Fake.On] with handlers,
+// so code using the transport can be tested without AWS access. Every API ID has the same routes.
+// It is safe for concurrent use.
+type Fake struct {
+	// Region is the region of the client options, [Region] when empty.
+	Region string
+
+	mu        sync.RWMutex
+	resources []*fakeResource
+}
+
+type fakeResource struct {
+	id       string
+	template string
+	handlers map[string]http.Handler
+}
+
+// NewFake creates a Fake without routes.
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// On serves the method requests to the resource template (e.g. /api/v1/users/{id}) with h.
+// Path variables are set in the request path values (r.PathValue("id")), ANY serves every method.
+func (f *Fake) On(method, template string, h http.HandlerFunc) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, res := range f.resources {
+		if res.template == template {
+			res.handlers[method] = h
+			return f
+		}
+	}
+
+	f.resources = append(f.resources, &fakeResource{
+		id:       fmt.Sprintf("fake%02d", len(f.resources)+1),
+		template: template,
+		handlers: map[string]http.Handler{method: h},
+	})
+
+	return f
+}
+
+func (f *Fake) GetResources(
+	context.Context,
+	*apigateway.GetResourcesInput,
+	...func(*apigateway.Options),
+) (*apigateway.GetResourcesOutput, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	out := &apigateway.GetResourcesOutput{}
+
+	for _, res := range f.resources {
+		methods := make(map[string]types.Method, len(res.handlers))
+		for method := range res.handlers {
+			methods[method] = types.Method{HttpMethod: aws.String(method)}
+		}
+
+		out.Items = append(out.Items, types.Resource{
+			Id:              aws.String(res.id),
+			Path:            aws.String(res.template),
+			ResourceMethods: methods,
+		})
+	}
+
+	return out, nil
+}
+
+func (f *Fake) TestInvokeMethod(
+	ctx context.Context,
+	input *apigateway.TestInvokeMethodInput,
+	_ ...func(*apigateway.Options),
+) (*apigateway.TestInvokeMethodOutput, error) {
+	method := aws.ToString(input.HttpMethod)
+
+	res, h := f.handler(aws.ToString(input.ResourceId), method)
+	if h == nil {
+		return nil, fmt.Errorf("fake: no %s method in resource %s", method, aws.ToString(input.ResourceId))
+	}
+
+	u, err := url.Parse(aws.ToString(input.PathWithQueryString))
+	if err != nil {
+		return nil, fmt.Errorf("fake: invalid path: %w", err)
+	}
+
+	r := httptest.NewRequest(method, u.String(), strings.NewReader(aws.ToString(input.Body))).WithContext(ctx)
+	r.Header = http.Header(input.MultiValueHeaders).Clone()
+
+	for name, value := range pathValues(res.template, u.Path) {
+		r.SetPathValue(name, value)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+
+	body, _ := io.ReadAll(rec.Result().Body)
+
+	return &apigateway.TestInvokeMethodOutput{
+		Status:            int32(rec.Code),
+		Body:              aws.String(string(body)),
+		MultiValueHeaders: rec.Result().Header,
+	}, nil
+}
+
+func (f *Fake) handler(resourceID, method string) (*fakeResource, http.Handler) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, res := range f.resources {
+		if res.id != resourceID {
+			continue
+		}
+
+		if h, found := res.handlers[method]; found {
+			return res, h
+		}
+
+		return res, res.handlers["ANY"]
+	}
+
+	return nil, nil
+}
+
+func (f *Fake) Options() apigateway.Options {
+	if f.Region == "" {
+		return apigateway.Options{Region: Region}
+	}
+
+	return apigateway.Options{Region: f.Region}
+}
+
+// pathValues returns the values of the template variables in path.
+func pathValues(template, path string) map[string]string {
+	values := map[string]string{}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, t := range strings.Split(strings.Trim(template, "/"), "/") {
+		if i >= len(segments) || !strings.HasPrefix(t, "{") {
+			continue
+		}
+
+		if name, greedy := strings.CutSuffix(strings.Trim(t, "{}"), "+"); greedy {
+			values[name] = strings.Join(segments[i:], "/")
+		} else {
+			values[name] = segments[i]
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,66 @@
+package transporttest_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rcarrion2/aws-apigw-invoke-transport"
+	"github.com/rcarrion2/aws-apigw-invoke-transport/transporttest"
+)
+
+func TestFake(t *testing.T) {
+	// GIVEN
+	fake := transporttest.NewFake().
+		On(http.MethodGet, "/api/v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"id":%q,"fields":%q}`, r.PathValue("id"), r.URL.Query().Get("fields"))
+		}).
+		On("ANY", "/api/v1/files/{path+}", func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = fmt.Fprintf(w, "%s %s %s %s", r.Method, r.PathValue("path"), r.Header.Get("X-Owner"), body)
+		})
+
+	tr, err := transport.NewInitializedTransport(fake, "abc123")
+	require.NoError(t, err)
+
+	cli := http.Client{Transport: tr}
+
+	// WHEN
+	getResp, getErr := cli.Get("https://abc123.execute-api.us-east-1.amazonaws.com/dev/api/v1/users/42?fields=name")
+
+	putReq, _ := http.NewRequest(http.MethodPut, "https://abc123.execute-api.us-east-1.amazonaws.com/dev/api/v1/files/a/b.txt", strings.NewReader("content"))
+	putReq.Header.Set("X-Owner", "alice")
+	putResp, putErr := cli.Do(putReq)
+
+	_, missErr := cli.Get("https://abc123.execute-api.us-east-1.amazonaws.com/dev/api/v1/orders")
+
+	// THEN
+	require.NoError(t, getErr)
+	assert.Equal(t, http.StatusOK, getResp.StatusCode)
+	assert.Equal(t, "application/json", getResp.Header.Get("Content-Type"))
+	assert.Equal(t, `{"id":"42","fields":"name"}`, readString(t, getResp.Body))
+
+	require.NoError(t, putErr)
+	assert.Equal(t, http.StatusCreated, putResp.StatusCode)
+	assert.Equal(t, "PUT a/b.txt alice content", readString(t, putResp.Body))
+
+	assert.ErrorIs(t, missErr, transport.ErrResourceNotFound)
+}
+
+func readString(t *testing.T, r io.ReadCloser) string {
+	t.Helper()
+
+	defer r.Close()
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	return string(b)
+}
@@ -0,0 +1,135 @@
+package transportv2
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewayv2"
+
+	"github.com/rcarrion2/aws-apigw-invoke-transport/internal/routetemplate"
+)
+
+const (
+	defaultRouteKey = "$default"
+	anyMethod       = "ANY"
+)
+
+func mapRoutes(ctx context.Context, cli ApiGwClient, apiID string) (routeMapping, error) {
+	mapping := routeMapping{}
+
+	input := &apigatewayv2.GetRoutesInput{ApiId: aws.String(apiID)}
+
+	for {
+		routes, err := cli.GetRoutes(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("get routes error: %w", err)
+		}
+
+		for _, r := range routes.Items {
+			if err = mapping.add(*r.RouteId, *r.RouteKey); err != nil {
+				return nil, err
+			}
+		}
+
+		if routes.NextToken == nil || *routes.NextToken == "" {
+			return mapping, nil
+		}
+
+		input.NextToken = routes.NextToken
+	}
+}
+
+type route struct {
+	// id is the aws api gateway route id.
+	id string
+	// path is the route key path (e.g. /path/to/{id}), empty for the $default route.
+	path  string
+	regex *regexp.Regexp
+}
+
+// routeMapping maps route keys (e.g. GET /path/to/{id}) to routes.
+type routeMapping map[string]route
+
+// match finds the route for the request, following HTTP APIs precedence:
+// method specific routes first, then ANY routes and finally the $default route.
+// Among the routes of a method matching the path, the most specific one is chosen (see [route.precedes]).
+func (mappings routeMapping) match(method, path string) (string, bool) {
+	for _, m := range []string{method, anyMethod} {
+		key := routeKey(m, path)
+
+		if _, found := mappings[key]; found {
+			return key, true
+		}
+
+		var (
+			bestKey string
+			best    route
+		)
+
+		for k, r := range mappings {
+			if r.regex != nil && r.regex.MatchString(key) && (bestKey == "" || r.precedes(best)) {
+				bestKey, best = k, r
+			}
+		}
+
+		if bestKey != "" {
+			return bestKey, true
+		}
+	}
+
+	if _, found := mappings[defaultRouteKey]; found {
+		return defaultRouteKey, true
+	}
+
+	return "", false
+}
+
+func (mappings routeMapping) add(routeID, key string) error {
+	r := route{id: routeID}
+
+	if key != defaultRouteKey {
+		regex, err := routeRegex(key)
+		if err != nil {
+			return err
+		}
+
+		_, r.path, _ = strings.Cut(key, " ")
+		r.regex = regex
+	}
+
+	mappings[key] = r
+
+	return nil
+}
+
+// precedes reports whether r has precedence over other when both match a request, see [routetemplate.Precedes].
+func (r route) precedes(other route) bool {
+	return routetemplate.Precedes(r.path, other.path)
+}
+
+func (mappings routeMapping) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(mappings))
+
+	for k, r := range mappings {
+		attrs = append(attrs, slog.String(k, r.id))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+func routeRegex(key string) (*regexp.Regexp, error) {
+	regex, err := routetemplate.Regex(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not compile route regex: %w", err)
+	}
+
+	return regex, nil
+}
+
+func routeKey(method, path string) string {
+	return strings.Join([]string{method, path}, " ") // e.g. POST /path/to/resource
+}
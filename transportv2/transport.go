@@ -0,0 +1,217 @@
+// Package transportv2 provides an [http.RoundTripper] for API Gateway HTTP APIs (apigatewayv2).
+//
+// HTTP APIs do not support test invocations, so the transport maps the API routes
+// and forwards the matched requests to the API endpoint through another [http.RoundTripper]
+// (e.g. one signing requests for IAM authorization).
+package transportv2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewayv2"
+)
+
+const defaultStage = "$default"
+
+var (
+	ErrRouteNotFound = errors.New("route not found")
+)
+
+// ApiGwClient is an [*apigatewayv2.Client] abstraction.
+type ApiGwClient interface {
+	GetApi(context.Context, *apigatewayv2.GetApiInput, ...func(*apigatewayv2.Options)) (*apigatewayv2.GetApiOutput, error)
+	GetRoutes(context.Context, *apigatewayv2.GetRoutesInput, ...func(*apigatewayv2.Options)) (*apigatewayv2.GetRoutesOutput, error)
+}
+
+// Transport is a [http.RoundTripper] that routes [http.Request] to an API Gateway HTTP API.
+type Transport struct {
+	apiID    string
+	stage    string
+	endpoint *url.URL
+	mapping  routeMapping
+
+	client   ApiGwClient
+	next     http.RoundTripper
+	log      *slog.Logger
+	initMu   sync.Mutex
+	initDone atomic.Bool
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx := r.Context()
+
+	if err := t.initMappings(ctx); err != nil {
+		return nil, err
+	}
+
+	t.log.DebugContext(ctx, "routes mapped", "routes", t.mapping)
+
+	path := r.URL.Path
+	if r.URL.Host == t.endpoint.Host {
+		path = t.removeStagePathPart(path)
+	}
+
+	key, hasRoute := t.mapping.match(r.Method, path)
+	if !hasRoute {
+		return nil, ErrRouteNotFound
+	}
+
+	t.log.DebugContext(ctx, "route matched", slog.String("route_key", key), slog.String("route_id", t.mapping[key].id))
+
+	resp, err := t.next.RoundTrip(t.endpointRequest(r, path))
+	if err != nil {
+		return nil, fmt.Errorf("forward error: %w", err)
+	}
+
+	return resp, nil
+}
+
+// endpointRequest clones the request targeting the API endpoint (and stage).
+func (t *Transport) endpointRequest(r *http.Request, path string) *http.Request {
+	out := r.Clone(r.Context())
+
+	out.URL.Scheme = t.endpoint.Scheme
+	out.URL.Host = t.endpoint.Host
+	out.URL.Path = t.stagePath() + path
+	out.URL.RawPath = ""
+	out.Host = ""
+
+	return out
+}
+
+func (t *Transport) stagePath() string {
+	if t.stage == "" || t.stage == defaultStage {
+		return ""
+	}
+
+	return "/" + t.stage
+}
+
+func (t *Transport) removeStagePathPart(path string) string {
+	if stagePath := t.stagePath(); stagePath != "" {
+		if trimmed, ok := strings.CutPrefix(path, stagePath); ok && (trimmed == "" || trimmed[0] == '/') {
+			return "/" + strings.TrimPrefix(trimmed, "/")
+		}
+	}
+
+	return path
+}
+
+// initMappings maps the routes once. A failed initialization is not kept, the next request retries it.
+// The initialization is not canceled with the request starting it, as concurrent requests wait for it.
+func (t *Transport) initMappings(ctx context.Context) error {
+	if t.initDone.Load() {
+		return nil
+	}
+
+	t.initMu.Lock()
+	defer t.initMu.Unlock()
+
+	if t.initDone.Load() {
+		return nil
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	t.log.DebugContext(ctx, "initializing route mappings")
+
+	api, err := t.client.GetApi(ctx, &apigatewayv2.GetApiInput{ApiId: aws.String(t.apiID)})
+	if err != nil {
+		return fmt.Errorf("get api error: %w", err)
+	}
+
+	endpoint, err := url.Parse(aws.ToString(api.ApiEndpoint))
+	if err != nil {
+		return fmt.Errorf("api endpoint error: %w", err)
+	}
+
+	mapping, err := mapRoutes(ctx, t.client, t.apiID)
+	if err != nil {
+		return err
+	}
+
+	t.endpoint, t.mapping = endpoint, mapping
+	t.initDone.Store(true)
+	t.log.DebugContext(ctx, "mappings ready")
+
+	return nil
+}
+
+// Mappings returns all routes mapped, it is empty until the routes are mapped.
+//
+// The key is the route key (e.g. GET /path/to/{id}) and the value the route id.
+func (t *Transport) Mappings() map[string]string {
+	if !t.initDone.Load() {
+		return map[string]string{}
+	}
+
+	result := make(map[string]string, len(t.mapping))
+
+	for k, r := range t.mapping {
+		result[k] = r.id
+	}
+
+	return result
+}
+
+func NewTransport(client ApiGwClient, apiID string, opts ...Option) *Transport {
+	t := &Transport{
+		apiID: apiID,
+		stage: defaultStage,
+
+		client: client,
+		next:   http.DefaultTransport,
+		log:    slog.New(slog.NewJSONHandler(io.Discard, nil)),
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	t.log = t.log.With(slog.String("api_id", t.apiID))
+
+	return t
+}
+
+func NewInitializedTransport(client ApiGwClient, apiID string, opts ...Option) (*Transport, error) {
+	t := NewTransport(client, apiID, opts...)
+
+	if err := t.initMappings(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+type Option func(*Transport)
+
+func WithLogger(l *slog.Logger) Option {
+	return func(t *Transport) {
+		t.log = l
+	}
+}
+
+// WithStage sets the stage requests are forwarded to. Defaults to $default.
+func WithStage(stage string) Option {
+	return func(t *Transport) {
+		t.stage = stage
+	}
+}
+
+// WithNextTransport sets the [http.RoundTripper] used to send the requests to the API endpoint.
+// Defaults to [http.DefaultTransport].
+func WithNextTransport(rt http.RoundTripper) Option {
+	return func(t *Transport) {
+		t.next = rt
+	}
+}
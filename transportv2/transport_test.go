@@ -0,0 +1,382 @@
+package transportv2_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewayv2"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewayv2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rcarrion2/aws-apigw-invoke-transport/transportv2"
+)
+
+func TestTransport_RoundTrip(t *testing.T) {
+	const apiID = "a1b2c3d4e5"
+
+	t.Run("should route request to the api endpoint", func(t *testing.T) {
+		testCases := map[string]struct {
+			method       string
+			path         string
+			expectedPath string
+		}{
+			"method route with path value": {
+				method:       http.MethodGet,
+				path:         "/api/v1/users/john.doe?attributes=age",
+				expectedPath: "/dev/api/v1/users/john.doe",
+			},
+			"any route": {
+				method:       http.MethodPost,
+				path:         "/api/v1/users",
+				expectedPath: "/dev/api/v1/users",
+			},
+			"greedy route": {
+				method:       http.MethodGet,
+				path:         "/files/docs/report.pdf",
+				expectedPath: "/dev/files/docs/report.pdf",
+			},
+			"default route": {
+				method:       http.MethodDelete,
+				path:         "/unknown",
+				expectedPath: "/dev/unknown",
+			},
+		}
+
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				// GIVEN
+				var received *http.Request
+
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					received = r
+					w.WriteHeader(http.StatusOK)
+					_, _ = w.Write([]byte(`{"ok":true}`))
+				}))
+				defer server.Close()
+
+				apiGwCli := new(apiGwClientMock)
+				apiGwCli.
+					On("GetApi", mock.Anything).
+					Return(&apigatewayv2.GetApiOutput{ApiEndpoint: aws.String(server.URL)}, nil).
+					Once()
+				apiGwCli.
+					On("GetRoutes", mock.Anything).
+					Return(&apigatewayv2.GetRoutesOutput{Items: createRoutes()}, nil).
+					Once()
+
+				tr := transportv2.NewTransport(apiGwCli, apiID, transportv2.WithStage("dev"))
+
+				httpReq, err := http.NewRequest(tc.method, "https://custom-domain.com"+tc.path, http.NoBody)
+				require.NoError(t, err)
+
+				// WHEN
+				httpResp, err := tr.RoundTrip(httpReq)
+
+				// THEN
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+				assert.JSONEq(t, `{"ok":true}`, readString(httpResp.Body))
+
+				require.NotNil(t, received)
+				assert.Equal(t, tc.expectedPath, received.URL.Path)
+				assert.Equal(t, httpReq.URL.RawQuery, received.URL.RawQuery)
+
+				apiGwCli.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("should match the most specific route", func(t *testing.T) {
+		testCases := map[string]struct {
+			method      string
+			path        string
+			expectedKey string
+		}{
+			"literal segment over path variable": {
+				method:      http.MethodGet,
+				path:        "/api/v1/users/me",
+				expectedKey: "GET /api/v1/users/me",
+			},
+			"path variable over greedy variable": {
+				method:      http.MethodGet,
+				path:        "/api/v1/users/john.doe",
+				expectedKey: "GET /api/v1/users/{value}",
+			},
+			"longer greedy route": {
+				method:      http.MethodGet,
+				path:        "/api/v1/files/report.pdf",
+				expectedKey: "GET /api/v1/{proxy+}",
+			},
+			"method route over any route": {
+				method:      http.MethodGet,
+				path:        "/api/v2/users",
+				expectedKey: "GET /api/{proxy+}",
+			},
+		}
+
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				// GIVEN
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}))
+				defer server.Close()
+
+				apiGwCli := new(apiGwClientMock)
+				apiGwCli.
+					On("GetApi", mock.Anything).
+					Return(&apigatewayv2.GetApiOutput{ApiEndpoint: aws.String(server.URL)}, nil).
+					Once()
+				apiGwCli.
+					On("GetRoutes", mock.Anything).
+					Return(&apigatewayv2.GetRoutesOutput{Items: []types.Route{
+						{RouteId: aws.String("r1"), RouteKey: aws.String("GET /{proxy+}")},
+						{RouteId: aws.String("r2"), RouteKey: aws.String("GET /api/{proxy+}")},
+						{RouteId: aws.String("r3"), RouteKey: aws.String("GET /api/v1/{proxy+}")},
+						{RouteId: aws.String("r4"), RouteKey: aws.String("GET /api/v1/users/{value}")},
+						{RouteId: aws.String("r5"), RouteKey: aws.String("GET /api/v1/users/me")},
+						{RouteId: aws.String("r6"), RouteKey: aws.String("ANY /api/v2/users")},
+					}}, nil).
+					Once()
+
+				var logs bytes.Buffer
+
+				tr := transportv2.NewTransport(apiGwCli, apiID, transportv2.WithLogger(
+					slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+
+				httpReq := httptest.NewRequest(tc.method, "https://custom-domain.com"+tc.path, http.NoBody)
+
+				// WHEN
+				_, err := tr.RoundTrip(httpReq)
+
+				// THEN
+				require.NoError(t, err)
+				assert.Contains(t, logs.String(), `"route_key":"`+tc.expectedKey+`"`)
+
+				apiGwCli.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("failed initialization should be retried", func(t *testing.T) {
+		// GIVEN
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		apiGwCli := new(apiGwClientMock)
+		apiGwCli.
+			On("GetApi", mock.Anything).
+			Return(nil, errors.New("throttled")).
+			Once()
+		apiGwCli.
+			On("GetApi", mock.Anything).
+			Return(&apigatewayv2.GetApiOutput{ApiEndpoint: aws.String(server.URL)}, nil).
+			Once()
+		apiGwCli.
+			On("GetRoutes", mock.Anything).
+			Return(&apigatewayv2.GetRoutesOutput{Items: createRoutes()}, nil).
+			Once()
+
+		tr := transportv2.NewTransport(apiGwCli, apiID)
+
+		canceled, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// WHEN
+		_, firstErr := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "/api/v1/users/john.doe", http.NoBody).WithContext(canceled))
+		httpResp, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "/api/v1/users/john.doe", http.NoBody))
+
+		// THEN
+		assert.EqualError(t, firstErr, "get api error: throttled")
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("route not found should return error", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(apiGwClientMock)
+		apiGwCli.
+			On("GetApi", mock.Anything).
+			Return(&apigatewayv2.GetApiOutput{ApiEndpoint: aws.String("https://" + apiID + ".execute-api.us-east-1.amazonaws.com")}, nil).
+			Once()
+		apiGwCli.
+			On("GetRoutes", mock.Anything).
+			Return(&apigatewayv2.GetRoutesOutput{Items: createRoutes()[:1]}, nil).
+			Once()
+
+		tr := transportv2.NewTransport(apiGwCli, apiID)
+
+		// WHEN
+		httpResp, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "/api/v1/posts", http.NoBody))
+
+		// THEN
+		assert.Zero(t, httpResp)
+		assert.ErrorIs(t, err, transportv2.ErrRouteNotFound)
+
+		apiGwCli.AssertExpectations(t)
+	})
+
+	t.Run("get routes error should return error", func(t *testing.T) {
+		// GIVEN
+		apiGwCli := new(apiGwClientMock)
+		apiGwCli.
+			On("GetApi", mock.Anything).
+			Return(&apigatewayv2.GetApiOutput{ApiEndpoint: aws.String("https://" + apiID + ".execute-api.us-east-1.amazonaws.com")}, nil).
+			Once()
+		apiGwCli.
+			On("GetRoutes", mock.Anything).
+			Return(nil, errors.New("something went wrong")).
+			Once()
+
+		// WHEN
+		tr, err := transportv2.NewInitializedTransport(apiGwCli, apiID)
+
+		// THEN
+		assert.Zero(t, tr)
+		assert.EqualError(t, err, "get routes error: something went wrong")
+
+		apiGwCli.AssertExpectations(t)
+	})
+}
+
+func TestTransport_Mappings(t *testing.T) {
+	// GIVEN
+	apiGwCli := new(apiGwClientMock)
+	apiGwCli.
+		On("GetApi", mock.Anything).
+		Return(&apigatewayv2.GetApiOutput{ApiEndpoint: aws.String("https://a1b2c3d4e5.execute-api.us-east-1.amazonaws.com")}, nil).
+		Once()
+	apiGwCli.
+		On("GetRoutes", mock.MatchedBy(func(i *apigatewayv2.GetRoutesInput) bool { return i.NextToken == nil })).
+		Return(&apigatewayv2.GetRoutesOutput{Items: createRoutes()[:2], NextToken: aws.String("next")}, nil).
+		Once()
+	apiGwCli.
+		On("GetRoutes", mock.MatchedBy(func(i *apigatewayv2.GetRoutesInput) bool { return aws.ToString(i.NextToken) == "next" })).
+		Return(&apigatewayv2.GetRoutesOutput{Items: createRoutes()[2:]}, nil).
+		Once()
+
+	tr, err := transportv2.NewInitializedTransport(apiGwCli, "a1b2c3d4e5")
+	require.NoError(t, err)
+
+	// WHEN
+	mappings := tr.Mappings()
+
+	// THEN
+	assert.Equal(t, map[string]string{
+		"GET /api/v1/users/{value}": "r1",
+		"ANY /api/v1/users":         "r2",
+		"GET /files/{proxy+}":       "r3",
+		"$default":                  "r4",
+	}, mappings)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+func TestTransport_Mappings_DuringInit(t *testing.T) {
+	// GIVEN
+	started, release := make(chan struct{}), make(chan struct{})
+
+	apiGwCli := new(apiGwClientMock)
+	apiGwCli.
+		On("GetApi", mock.Anything).
+		Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		}).
+		Return(&apigatewayv2.GetApiOutput{ApiEndpoint: aws.String("https://a1b2c3d4e5.execute-api.us-east-1.amazonaws.com")}, nil).
+		Once()
+	apiGwCli.
+		On("GetRoutes", mock.Anything).
+		Return(&apigatewayv2.GetRoutesOutput{Items: createRoutes()[:3]}, nil).
+		Once()
+
+	tr := transportv2.NewTransport(apiGwCli, "a1b2c3d4e5")
+
+	initDone := make(chan struct{})
+
+	go func() {
+		defer close(initDone)
+		_, _ = tr.RoundTrip(httptest.NewRequest(http.MethodGet, "/unknown/route", http.NoBody)) // not forwarded, no $default route
+	}()
+
+	// WHEN
+	<-started
+	during := tr.Mappings()
+
+	close(release)
+	<-initDone
+
+	after := tr.Mappings()
+
+	// THEN
+	assert.Empty(t, during)
+	assert.Len(t, after, 3)
+
+	apiGwCli.AssertExpectations(t)
+}
+
+type apiGwClientMock struct{ mock.Mock }
+
+func (m *apiGwClientMock) GetApi(
+	_ context.Context,
+	input *apigatewayv2.GetApiInput,
+	_ ...func(*apigatewayv2.Options),
+) (*apigatewayv2.GetApiOutput, error) {
+	args := m.Called(input)
+
+	var out *apigatewayv2.GetApiOutput
+
+	if args.Get(0) != nil {
+		out = args.Get(0).(*apigatewayv2.GetApiOutput)
+	}
+
+	return out, args.Error(1)
+}
+
+func (m *apiGwClientMock) GetRoutes(
+	_ context.Context,
+	input *apigatewayv2.GetRoutesInput,
+	_ ...func(*apigatewayv2.Options),
+) (*apigatewayv2.GetRoutesOutput, error) {
+	args := m.Called(input)
+
+	var out *apigatewayv2.GetRoutesOutput
+
+	if args.Get(0) != nil {
+		out = args.Get(0).(*apigatewayv2.GetRoutesOutput)
+	}
+
+	return out, args.Error(1)
+}
+
+func createRoutes() []types.Route {
+	return []types.Route{
+		{RouteId: aws.String("r1"), RouteKey: aws.String("GET /api/v1/users/{value}")},
+		{RouteId: aws.String("r2"), RouteKey: aws.String("ANY /api/v1/users")},
+		{RouteId: aws.String("r3"), RouteKey: aws.String("GET /files/{proxy+}")},
+		{RouteId: aws.String("r4"), RouteKey: aws.String("$default")},
+	}
+}
+
+func readString(r io.Reader) string {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		panic(err)
+	}
+
+	return string(data)
+}
@@ -0,0 +1,113 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+)
+
+const methodRequestPrefix = "method.request."
+
+// MissingParametersError is returned when a request lacks parameters that the API Gateway
+// method marks as required. API Gateway would reject such request with 400 Bad Request.
+type MissingParametersError struct {
+	Method string
+	Path   string
+
+	// Parameters are the missing ones, in the form location.name (e.g. querystring.limit, header.X-Api-Key).
+	Parameters []string
+}
+
+func (e *MissingParametersError) Error() string {
+	return fmt.Sprintf("%s for %s %s: %s",
+		ErrMissingRequestParameters, e.Method, e.Path, strings.Join(e.Parameters, ", "))
+}
+
+func (e *MissingParametersError) Unwrap() error {
+	return ErrMissingRequestParameters
+}
+
+// StatusCode returns the status API Gateway would respond with.
+func (e *MissingParametersError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// requiredParameters returns the method request parameters marked as required,
+// without the method.request prefix (e.g. querystring.name).
+func requiredParameters(m types.Method) []string {
+	var params []string
+
+	for name, required := range m.RequestParameters {
+		if required {
+			params = append(params, strings.TrimPrefix(name, methodRequestPrefix))
+		}
+	}
+
+	slices.Sort(params)
+
+	return params
+}
+
+// validateRequiredParameters checks r has the required parameters of res, path is the matched path.
+func validateRequiredParameters(r *http.Request, res resource, path string) error {
+	var missing []string
+
+	query := r.URL.Query()
+
+	for _, param := range res.requiredParams {
+		location, name, _ := strings.Cut(param, ".")
+
+		var present bool
+
+		switch location {
+		case "querystring":
+			present = query.Has(name)
+		case "header":
+			present = len(r.Header.Values(name)) > 0
+		case "path":
+			present = strings.Contains(res.path, "{"+name+"}") || strings.Contains(res.path, "{"+name+"+}")
+		default:
+			present = true // unknown locations are left to API Gateway.
+		}
+
+		if !present {
+			missing = append(missing, param)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &MissingParametersError{Method: r.Method, Path: path, Parameters: missing}
+	}
+
+	return nil
+}
+
+// BodyTooLargeError is returned when a request body exceeds the limit set with [WithMaxBodySize].
+type BodyTooLargeError struct {
+	Limit int64
+	Size  int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("%s: %d bytes exceeds the %d bytes limit", ErrBodyTooLarge, e.Size, e.Limit)
+}
+
+func (e *BodyTooLargeError) Unwrap() error {
+	return ErrBodyTooLarge
+}
+
+// StatusCode returns the status API Gateway would respond with.
+func (e *BodyTooLargeError) StatusCode() int {
+	return http.StatusRequestEntityTooLarge
+}
+
+// WithMaxBodySize fails requests whose body is larger than limit bytes with a [*BodyTooLargeError],
+// before invoking, instead of failing in the SDK because of the TestInvokeMethod payload limits.
+func WithMaxBodySize(limit int64) Option {
+	return func(t *Transport) {
+		t.maxBodySize = limit
+	}
+}
@@ -0,0 +1,82 @@
+package transport
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// WarmUp initializes the transport mappings in the background, so a service can start serving while
+// they load. The returned channel receives the initialization result and is closed. Requests arriving
+// meanwhile wait for the same initialization, as with concurrent requests.
+func (t *Transport) WarmUp(ctx context.Context) <-chan error {
+	done := make(chan error, 1)
+
+	go func() {
+		defer close(done)
+		done <- t.initMappings(ctx)
+	}()
+
+	return done
+}
+
+// Ready reports whether the mappings are initialized successfully, e.g. for readiness probes.
+func (t *Transport) Ready() bool {
+	return t.initDone.Load() && t.initErr == nil
+}
+
+// WithAsyncInit starts the initialization when the transport is created, see [Transport.WarmUp],
+// and calls ready with its result, ready may be nil.
+func WithAsyncInit(ready func(err error)) Option {
+	return func(t *Transport) {
+		t.asyncInit = true
+		t.asyncInitReady = ready
+	}
+}
+
+func (t *Transport) startAsyncInit() {
+	go func() {
+		err := t.initMappings(context.Background())
+
+		if t.asyncInitReady != nil {
+			t.asyncInitReady(err)
+		}
+	}()
+}
+
+const (
+	defaultInitBackoff = time.Second
+	maxInitBackoff     = time.Minute
+)
+
+// WithInitRetry sets how the failed initializations are retried: the requests following a failure
+// get its error without calling the API until backoff elapsed, the backoff doubling after each
+// failure up to a minute. After maxAttempts failures, the last error is kept and returned by all
+// the following requests, zero (the default) retries forever. The default backoff is a second.
+// Successful initializations are kept.
+func WithInitRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(t *Transport) {
+		t.initMaxAttempts = maxAttempts
+		t.initBackoff = backoff
+	}
+}
+
+// initFailed records a failed initialization, t.initMu is held.
+func (t *Transport) initFailed(ctx context.Context, err error) {
+	t.initErr = err
+	t.initFailures++
+
+	if t.initMaxAttempts > 0 && t.initFailures >= t.initMaxAttempts {
+		t.initDone.Store(true)
+		t.initLog.WarnContext(ctx, "mappings initialization failed, giving up",
+			slog.Int("attempt", t.initFailures), slog.Any("error", err))
+
+		return
+	}
+
+	backoff := min(t.initBackoff<<min(t.initFailures-1, 16), max(t.initBackoff, maxInitBackoff))
+	t.initRetryAt = t.clock.Now().Add(backoff)
+
+	t.initLog.WarnContext(ctx, "mappings initialization failed",
+		slog.Int("attempt", t.initFailures), slog.Duration("retry_in", backoff), slog.Any("error", err))
+}